@@ -0,0 +1,107 @@
+package heimdall
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// latencyWindowSize is the number of recent latencies the adaptive timeout is
+	// derived from
+	latencyWindowSize = 100
+
+	// minLatencySamples is the number of latencies observed before the timeout adapts
+	minLatencySamples = 10
+)
+
+// AdaptiveTimeout configures the request timeouts adapted to the recent latencies of
+// heimdall, set with WithAdaptiveTimeout
+type AdaptiveTimeout struct {
+	// Percentile of the recent latencies the timeout is derived from, 0.99 if unset
+	Percentile float64
+
+	// Factor applied to the percentile, 2 if unset
+	Factor float64
+
+	// Floor and Ceiling bound the timeout. The ceiling is the default timeout if unset.
+	Floor   time.Duration
+	Ceiling time.Duration
+}
+
+// adaptiveTimeout derives the timeouts from a rolling window of latencies
+type adaptiveTimeout struct {
+	config AdaptiveTimeout
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	next      int
+}
+
+func newAdaptiveTimeout(config AdaptiveTimeout) *adaptiveTimeout {
+	if config.Percentile <= 0 || config.Percentile > 1 {
+		config.Percentile = 0.99
+	}
+
+	if config.Factor <= 0 {
+		config.Factor = 2
+	}
+
+	if config.Ceiling <= 0 {
+		config.Ceiling = apiHeimdallTimeout
+	}
+
+	return &adaptiveTimeout{
+		config:    config,
+		latencies: make([]time.Duration, 0, latencyWindowSize),
+	}
+}
+
+// observe records the latency of an attempt, replacing the oldest one once the
+// window is full
+func (a *adaptiveTimeout) observe(latency time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.latencies) < latencyWindowSize {
+		a.latencies = append(a.latencies, latency)
+		return
+	}
+
+	a.latencies[a.next] = latency
+	a.next = (a.next + 1) % latencyWindowSize
+}
+
+// timeout returns the percentile of the recent latencies times the factor, within
+// the bounds, or the given timeout until enough latencies were observed
+func (a *adaptiveTimeout) timeout(fallback time.Duration) time.Duration {
+	a.mu.Lock()
+
+	if len(a.latencies) < minLatencySamples {
+		a.mu.Unlock()
+		return fallback
+	}
+
+	sorted := make([]time.Duration, len(a.latencies))
+	copy(sorted, a.latencies)
+	a.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	index := int(math.Ceil(a.config.Percentile*float64(len(sorted)))) - 1
+	if index < 0 {
+		index = 0
+	}
+
+	timeout := time.Duration(float64(sorted[index]) * a.config.Factor)
+
+	switch {
+	case timeout < a.config.Floor:
+		return a.config.Floor
+	case timeout > a.config.Ceiling:
+		return a.config.Ceiling
+	}
+
+	return timeout
+}
@@ -0,0 +1,82 @@
+package heimdall
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdaptiveTimeout(t *testing.T) {
+	t.Parallel()
+
+	const (
+		floor   = 50 * time.Millisecond
+		ceiling = time.Second
+	)
+
+	var latency atomic.Int64
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Duration(latency.Load())):
+		case <-r.Context().Done():
+			return
+		}
+
+		writeMilestone(w, 1)
+	}))
+	defer srv.Close()
+
+	client := NewHeimdallClient(srv.URL, WithAdaptiveTimeout(AdaptiveTimeout{Floor: floor, Ceiling: ceiling}))
+	defer client.Close()
+
+	client.retryInterval = time.Millisecond
+
+	// the policy timeout applies until enough latencies were observed
+	require.Equal(t, apiHeimdallTimeout, client.adaptiveTimeout.timeout(apiHeimdallTimeout))
+
+	for i := 0; i < minLatencySamples; i++ {
+		_, err := client.FetchMilestone(context.Background())
+		require.NoError(t, err)
+	}
+
+	// a fast heimdall gets a tight timeout
+	require.Equal(t, floor, client.adaptiveTimeout.timeout(apiHeimdallTimeout))
+
+	// the attempts timing out as the latency rises grow the timeout back
+	latency.Store(int64(150 * time.Millisecond))
+
+	var meta FetchMeta
+
+	_, err := client.FetchMilestone(WithFetchMeta(context.Background(), &meta))
+	require.NoError(t, err)
+	require.Greater(t, meta.Attempts(), 1, "expect the first attempts to time out")
+
+	timeout := client.adaptiveTimeout.timeout(apiHeimdallTimeout)
+	require.Greater(t, timeout, 150*time.Millisecond)
+	require.LessOrEqual(t, timeout, ceiling)
+}
+
+func TestAdaptiveTimeoutBounds(t *testing.T) {
+	t.Parallel()
+
+	adaptive := newAdaptiveTimeout(AdaptiveTimeout{Percentile: 0.5, Factor: 3, Floor: time.Millisecond, Ceiling: time.Second})
+
+	for i := 0; i < latencyWindowSize; i++ {
+		adaptive.observe(10 * time.Millisecond)
+	}
+
+	require.Equal(t, 30*time.Millisecond, adaptive.timeout(apiHeimdallTimeout))
+
+	// the window rolls over to the recent latencies
+	for i := 0; i < latencyWindowSize; i++ {
+		adaptive.observe(time.Minute)
+	}
+
+	require.Equal(t, time.Second, adaptive.timeout(apiHeimdallTimeout))
+}
@@ -0,0 +1,117 @@
+package heimdall
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting heimdall while the circuit breaker
+// of the endpoint is open
+var ErrCircuitOpen = errors.New("heimdall circuit breaker is open")
+
+// circuitBreaker stops requests to an endpoint after consecutive failures. Once the
+// cooldown elapsed, a single probe request is let through and closes the breaker
+// on success, or keeps it open for another cooldown on failure.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// allow reports whether a request can be sent
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+
+	if b.probing || time.Since(b.openedAt) < b.cooldown {
+		return false
+	}
+
+	b.probing = true
+
+	return true
+}
+
+// record updates the breaker with the outcome of a request
+func (b *circuitBreaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+
+	if success {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+
+	if b.failures >= b.threshold {
+		b.openedAt = time.Now()
+	}
+}
+
+// release lets another probe through after a probe whose outcome is unknown, like
+// one cancelled by its caller, without counting it as a failure
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// circuitBreakers holds a breaker per request type, or a single one if shared, kept
+// apart for the primary, the replica and the canary heimdall
+type circuitBreakers struct {
+	threshold int
+	cooldown  time.Duration
+	shared    bool
+
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+// get returns the breaker of the endpoint requested with the given context and url
+// path, creating it on first use
+func (c *circuitBreakers) get(ctx context.Context, path string) *circuitBreaker {
+	key := path
+
+	if reqType, ok := getRequestType(ctx); ok {
+		key = string(reqType)
+	}
+
+	if c.shared {
+		key = ""
+	}
+
+	// a failing replica mustn't open the breaker of the primary it falls back to
+	if replica, _ := ctx.Value(replicaKey{}).(bool); replica {
+		key = "replica/" + key
+	}
+
+	// nor a failing canary the breaker of the primary it is checked against
+	if canary, _ := ctx.Value(canaryKey{}).(bool); canary {
+		key = "canary/" + key
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	breaker, ok := c.breakers[key]
+	if !ok {
+		breaker = &circuitBreaker{threshold: c.threshold, cooldown: c.cooldown}
+		c.breakers[key] = breaker
+	}
+
+	return breaker
+}
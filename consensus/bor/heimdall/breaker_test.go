@@ -0,0 +1,140 @@
+package heimdall
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/checkpoint"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newSpanFailingServer starts a server failing every span request, while serving
+// the latest checkpoint
+func newSpanFailingServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var spanRequests atomic.Int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/bor/span/1", func(w http.ResponseWriter, _ *http.Request) {
+		spanRequests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/checkpoints/latest", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(checkpoint.CheckpointResponse{
+			Height: "0",
+			Result: checkpoint.Checkpoint{EndBlock: big.NewInt(512)},
+		})
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv, &spanRequests
+}
+
+func TestCircuitBreakerPerEndpoint(t *testing.T) {
+	t.Parallel()
+
+	srv, spanRequests := newSpanFailingServer(t)
+
+	client := NewHeimdallClient(srv.URL, WithRetryBudget(2), WithCircuitBreaker(2, time.Minute))
+	client.retryInterval = 10 * time.Millisecond
+
+	// Trip the span breaker
+	_, err := client.Span(context.Background(), 1)
+	require.ErrorIs(t, err, ErrNotSuccessfulResponse)
+	require.Equal(t, int32(2), spanRequests.Load())
+
+	_, err = client.Span(context.Background(), 1)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	require.Equal(t, int32(2), spanRequests.Load(), "expect no request while the breaker is open")
+
+	// The checkpoint endpoint has its own breaker
+	_, err = client.FetchCheckpoint(context.Background(), -1)
+	require.NoError(t, err)
+}
+
+func TestCircuitBreakerShared(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := newSpanFailingServer(t)
+
+	client := NewHeimdallClient(srv.URL, WithRetryBudget(2), WithCircuitBreaker(2, time.Minute), WithSharedCircuitBreaker())
+	client.retryInterval = 10 * time.Millisecond
+
+	_, err := client.Span(context.Background(), 1)
+	require.ErrorIs(t, err, ErrNotSuccessfulResponse)
+
+	_, err = client.FetchCheckpoint(context.Background(), -1)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+}
+
+func TestCircuitBreakerProbe(t *testing.T) {
+	t.Parallel()
+
+	breaker := &circuitBreaker{threshold: 2, cooldown: 20 * time.Millisecond}
+
+	breaker.record(false)
+	require.True(t, breaker.allow())
+
+	breaker.record(false)
+	require.False(t, breaker.allow(), "expect the breaker to open after the threshold")
+
+	time.Sleep(30 * time.Millisecond)
+
+	require.True(t, breaker.allow(), "expect a probe after the cooldown")
+	require.False(t, breaker.allow(), "expect a single probe at a time")
+
+	breaker.record(false)
+	require.False(t, breaker.allow(), "expect a failed probe to reopen the breaker")
+
+	time.Sleep(30 * time.Millisecond)
+
+	require.True(t, breaker.allow())
+	breaker.record(true)
+	require.True(t, breaker.allow(), "expect a successful probe to close the breaker")
+	require.True(t, breaker.allow())
+}
+
+func TestCircuitBreakerCancelledProbe(t *testing.T) {
+	t.Parallel()
+
+	var healthy atomic.Bool
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		writeMilestone(w, 1)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := NewHeimdallClient(srv.URL, WithRetryBudget(1), WithCircuitBreaker(1, 20*time.Millisecond))
+
+	_, err := client.FetchMilestone(context.Background())
+	require.ErrorIs(t, err, ErrNotSuccessfulResponse)
+
+	time.Sleep(30 * time.Millisecond)
+
+	// the probe is cancelled by its caller before its outcome is known
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = client.FetchMilestone(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+
+	healthy.Store(true)
+
+	_, err = client.FetchMilestone(context.Background())
+	require.NoError(t, err, "expect the cancelled probe to let another one through")
+}
@@ -0,0 +1,94 @@
+package heimdall
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheTTL(t *testing.T) {
+	t.Parallel()
+
+	const defaultTTL = 10 * time.Second
+
+	tests := []struct {
+		cacheControl string
+		ttl          time.Duration
+		cacheable    bool
+	}{
+		{cacheControl: "", ttl: defaultTTL, cacheable: true},
+		{cacheControl: "max-age=60", ttl: time.Minute, cacheable: true},
+		{cacheControl: "public, max-age=5", ttl: 5 * time.Second, cacheable: true},
+		{cacheControl: "max-age=0", ttl: 0, cacheable: true},
+		{cacheControl: "no-store", cacheable: false},
+		{cacheControl: "max-age=60, no-cache", cacheable: false},
+		{cacheControl: "No-Cache", cacheable: false},
+		{cacheControl: "max-age=soon", cacheable: false},
+	}
+
+	for _, test := range tests {
+		header := http.Header{}
+		if test.cacheControl != "" {
+			header.Set("Cache-Control", test.cacheControl)
+		}
+
+		ttl, cacheable := cacheTTL(header, defaultTTL)
+		require.Equal(t, test.cacheable, cacheable, test.cacheControl)
+
+		if test.cacheable {
+			require.Equal(t, test.ttl, ttl, test.cacheControl)
+		}
+	}
+}
+
+func TestResponseCache(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		cacheControl string
+		requests     int32
+	}{
+		{name: "default ttl", requests: 1},
+		{name: "max-age", cacheControl: "max-age=60", requests: 1},
+		{name: "max-age=0", cacheControl: "max-age=0", requests: 2},
+		{name: "no-store", cacheControl: "no-store", requests: 2},
+		{name: "no-cache", cacheControl: "no-cache", requests: 2},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var requests atomic.Int32
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				requests.Add(1)
+
+				if test.cacheControl != "" {
+					w.Header().Set("Cache-Control", test.cacheControl)
+				}
+
+				_, _ = w.Write([]byte(`{"height":"0","result":{"result":7}}`))
+			}))
+			t.Cleanup(srv.Close)
+
+			client := NewHeimdallClient(srv.URL, WithResponseCache(time.Minute))
+
+			for i := 0; i < 2; i++ {
+				count, err := client.FetchCheckpointCount(context.Background())
+				require.NoError(t, err)
+				require.Equal(t, int64(7), count)
+			}
+
+			require.Equal(t, test.requests, requests.Load())
+		})
+	}
+}
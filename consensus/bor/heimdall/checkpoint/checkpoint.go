@@ -1,9 +1,12 @@
 package checkpoint
 
 import (
+	"encoding/json"
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/internal/numbers"
 )
 
 // Checkpoint defines a response object type of bor checkpoint
@@ -16,16 +19,119 @@ type Checkpoint struct {
 	Timestamp  uint64         `json:"timestamp"`
 }
 
+// UnmarshalJSON decodes the block numbers from either numbers or strings, as served
+// by different Heimdall versions
+func (c *Checkpoint) UnmarshalJSON(data []byte) error {
+	type plain Checkpoint
+
+	var decoded struct {
+		plain
+		StartBlock json.RawMessage `json:"start_block"`
+		EndBlock   json.RawMessage `json:"end_block"`
+	}
+
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	startBlock, err := numbers.ParseBigInt(decoded.StartBlock)
+	if err != nil {
+		return fmt.Errorf("decoding checkpoint start block: %w", err)
+	}
+
+	endBlock, err := numbers.ParseBigInt(decoded.EndBlock)
+	if err != nil {
+		return fmt.Errorf("decoding checkpoint end block: %w", err)
+	}
+
+	*c = Checkpoint(decoded.plain)
+	c.StartBlock = startBlock
+	c.EndBlock = endBlock
+
+	return nil
+}
+
 type CheckpointResponse struct {
 	Height string     `json:"height"`
 	Result Checkpoint `json:"result"`
 }
 
+// CheckpointEndBlock is the projection of a checkpoint on its end block, for the
+// callers needing only that field. The other fields aren't decoded.
+type CheckpointEndBlock struct {
+	EndBlock *big.Int `json:"end_block"`
+}
+
+// UnmarshalJSON decodes the end block from either a number or a string, like the
+// checkpoint
+func (c *CheckpointEndBlock) UnmarshalJSON(data []byte) error {
+	var decoded struct {
+		EndBlock json.RawMessage `json:"end_block"`
+	}
+
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	endBlock, err := numbers.ParseBigInt(decoded.EndBlock)
+	if err != nil {
+		return fmt.Errorf("decoding checkpoint end block: %w", err)
+	}
+
+	c.EndBlock = endBlock
+
+	return nil
+}
+
+type CheckpointEndBlockResponse struct {
+	Height string             `json:"height"`
+	Result CheckpointEndBlock `json:"result"`
+}
+
 type CheckpointCount struct {
 	Result int64 `json:"result"`
 }
 
+// UnmarshalJSON decodes the count from either the nested {"result": 5} object or a
+// bare number or string, as served by different Heimdall versions
+func (c *CheckpointCount) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '{' {
+		var nested struct {
+			Result json.RawMessage `json:"result"`
+		}
+
+		if err := json.Unmarshal(data, &nested); err != nil {
+			return err
+		}
+
+		data = nested.Result
+	}
+
+	count, err := numbers.ParseCount(data)
+	if err != nil {
+		return err
+	}
+
+	c.Result = count
+
+	return nil
+}
+
 type CheckpointCountResponse struct {
 	Height string          `json:"height"`
 	Result CheckpointCount `json:"result"`
 }
+
+// NextCheckpointRange returns the block range expected to be covered by the
+// checkpoint following cp, given the checkpoint size. The range starts right after
+// the end block of cp. Both bounds are nil if cp has no end block or size is zero.
+func NextCheckpointRange(cp *Checkpoint, size uint64) (start, end *big.Int) {
+	if cp == nil || cp.EndBlock == nil || cp.EndBlock.Sign() < 0 || size == 0 {
+		return nil, nil
+	}
+
+	start = new(big.Int).Add(cp.EndBlock, big.NewInt(1))
+	end = new(big.Int).Add(cp.EndBlock, new(big.Int).SetUint64(size))
+
+	return start, end
+}
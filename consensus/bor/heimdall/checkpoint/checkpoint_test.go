@@ -0,0 +1,163 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointCountUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "nested object", body: `{"height":"0","result":{"result":5}}`},
+		{name: "nested string", body: `{"height":"0","result":{"result":"5"}}`},
+		{name: "bare number", body: `{"height":"0","result":5}`},
+		{name: "bare string", body: `{"height":"0","result":"5"}`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var response CheckpointCountResponse
+
+			require.NoError(t, json.Unmarshal([]byte(test.body), &response))
+			require.Equal(t, int64(5), response.Result.Result)
+		})
+	}
+
+	var response CheckpointCountResponse
+	require.Error(t, json.Unmarshal([]byte(`{"result":"five"}`), &response))
+
+	// a null count decodes to 0, like before the string counts were supported
+	require.NoError(t, json.Unmarshal([]byte(`{"height":"0","result":{"result":null}}`), &response))
+	require.Zero(t, response.Result.Result)
+}
+
+func TestNextCheckpointRange(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		checkpoint *Checkpoint
+		size       uint64
+		start, end *big.Int
+	}{
+		{
+			name:       "regular checkpoint",
+			checkpoint: &Checkpoint{StartBlock: big.NewInt(0), EndBlock: big.NewInt(255)},
+			size:       256,
+			start:      big.NewInt(256),
+			end:        big.NewInt(511),
+		},
+		{
+			name:       "checkpoint of a single block",
+			checkpoint: &Checkpoint{StartBlock: big.NewInt(10), EndBlock: big.NewInt(10)},
+			size:       1,
+			start:      big.NewInt(11),
+			end:        big.NewInt(11),
+		},
+		{
+			name:       "genesis block",
+			checkpoint: &Checkpoint{StartBlock: big.NewInt(0), EndBlock: big.NewInt(0)},
+			size:       1024,
+			start:      big.NewInt(1),
+			end:        big.NewInt(1024),
+		},
+		{
+			name:       "beyond uint64",
+			checkpoint: &Checkpoint{EndBlock: new(big.Int).SetUint64(math.MaxUint64)},
+			size:       2,
+			start:      new(big.Int).Add(new(big.Int).SetUint64(math.MaxUint64), big.NewInt(1)),
+			end:        new(big.Int).Add(new(big.Int).SetUint64(math.MaxUint64), big.NewInt(2)),
+		},
+		{
+			name:       "zero size",
+			checkpoint: &Checkpoint{EndBlock: big.NewInt(255)},
+		},
+		{
+			name:       "missing end block",
+			checkpoint: &Checkpoint{StartBlock: big.NewInt(0)},
+			size:       256,
+		},
+		{
+			name:       "negative end block",
+			checkpoint: &Checkpoint{EndBlock: big.NewInt(-1)},
+			size:       256,
+		},
+		{
+			name: "nil checkpoint",
+			size: 256,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			start, end := NextCheckpointRange(test.checkpoint, test.size)
+			require.Equal(t, test.start, start)
+			require.Equal(t, test.end, end)
+		})
+	}
+}
+
+func TestCheckpointBlockNumbersUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		body       string
+		start, end *big.Int
+	}{
+		{name: "numbers", body: `{"start_block":256,"end_block":512}`, start: big.NewInt(256), end: big.NewInt(512)},
+		{name: "strings", body: `{"start_block":"256","end_block":"512"}`, start: big.NewInt(256), end: big.NewInt(512)},
+		{name: "mixed", body: `{"start_block":256,"end_block":"512"}`, start: big.NewInt(256), end: big.NewInt(512)},
+		{name: "missing and null", body: `{"end_block":null}`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var decoded Checkpoint
+
+			require.NoError(t, json.Unmarshal([]byte(test.body), &decoded))
+			require.Equal(t, test.start, decoded.StartBlock)
+			require.Equal(t, test.end, decoded.EndBlock)
+		})
+	}
+
+	var decoded Checkpoint
+	require.Error(t, json.Unmarshal([]byte(`{"end_block":"five"}`), &decoded))
+}
+
+func TestCheckpointEndBlockUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	const body = `{"proposer":"0x0000000000000000000000000000000000000001","start_block":256,"end_block":"512","root_hash":"0x01","bor_chain_id":"137","timestamp":1}`
+
+	var decoded CheckpointEndBlock
+
+	require.NoError(t, json.Unmarshal([]byte(body), &decoded))
+	require.Equal(t, big.NewInt(512), decoded.EndBlock)
+
+	// the other fields aren't decoded, so their errors don't matter
+	require.NoError(t, json.Unmarshal([]byte(`{"start_block":"five","end_block":512}`), &decoded))
+	require.Equal(t, big.NewInt(512), decoded.EndBlock)
+
+	require.Error(t, json.Unmarshal([]byte(`{"end_block":"five"}`), &decoded))
+}
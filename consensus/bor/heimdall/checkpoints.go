@@ -0,0 +1,159 @@
+package heimdall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/checkpoint"
+)
+
+const (
+	// checkpointFetchConcurrency is the number of checkpoints fetched at once by
+	// CheckpointsByNumbers
+	checkpointFetchConcurrency = 4
+
+	// maxCheckpointPollBackoff bounds the wait between the polls of FetchCheckpointAfter
+	maxCheckpointPollBackoff = time.Minute
+)
+
+// ErrInvalidCheckpointNumber is returned for the checkpoint numbers which heimdall
+// can't be asked for, those beyond math.MaxInt64
+var ErrInvalidCheckpointNumber = errors.New("invalid checkpoint number")
+
+// CheckpointErrors holds the errors of the checkpoints which couldn't be fetched, by
+// checkpoint number
+type CheckpointErrors map[uint64]error
+
+func (e CheckpointErrors) Error() string {
+	numbers := make([]uint64, 0, len(e))
+	for number := range e {
+		numbers = append(numbers, number)
+	}
+
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+
+	failures := make([]string, 0, len(numbers))
+	for _, number := range numbers {
+		failures = append(failures, fmt.Sprintf("checkpoint %d: %v", number, e[number]))
+	}
+
+	return "fetching checkpoints failed: " + strings.Join(failures, "; ")
+}
+
+// CheckpointsByNumbers fetches the checkpoints with the given numbers concurrently. The
+// fetched ones are returned by number even if others fail, along with CheckpointErrors
+// holding the failures.
+func (h *HeimdallClient) CheckpointsByNumbers(ctx context.Context, numbers []uint64) (map[uint64]*checkpoint.Checkpoint, error) {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs = make(CheckpointErrors)
+
+		checkpoints = make(map[uint64]*checkpoint.Checkpoint, len(numbers))
+		queue       = make(chan uint64)
+	)
+
+	for i := 0; i < checkpointFetchConcurrency && i < len(numbers); i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for number := range queue {
+				var (
+					cp  *checkpoint.Checkpoint
+					err error
+				)
+
+				// the numbers beyond math.MaxInt64 would wrap, -1 fetching the latest one
+				if number > math.MaxInt64 {
+					err = fmt.Errorf("%w: %d", ErrInvalidCheckpointNumber, number)
+				} else {
+					select {
+					case <-ctx.Done():
+						err = ctx.Err()
+					case <-h.closeCh:
+						err = ErrShutdownDetected
+					default:
+						cp, err = h.FetchCheckpoint(ctx, int64(number))
+					}
+				}
+
+				mu.Lock()
+				if err != nil {
+					errs[number] = err
+				} else {
+					checkpoints[number] = cp
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var stopErr error
+
+feed:
+	for i, number := range numbers {
+		select {
+		case queue <- number:
+		case <-ctx.Done():
+			stopErr = ctx.Err()
+		case <-h.closeCh:
+			stopErr = ErrShutdownDetected
+		}
+
+		if stopErr != nil {
+			mu.Lock()
+			for _, number := range numbers[i:] {
+				errs[number] = stopErr
+			}
+			mu.Unlock()
+
+			break feed
+		}
+	}
+
+	close(queue)
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return checkpoints, errs
+	}
+
+	return checkpoints, nil
+}
+
+// FetchCheckpointAfter polls the latest checkpoint until one ending after the given
+// block appears, and returns it. The polls are spaced from the retry interval,
+// doubling up to a minute, until the context is done or the client is closed.
+func (h *HeimdallClient) FetchCheckpointAfter(ctx context.Context, afterEndBlock uint64) (*checkpoint.Checkpoint, error) {
+	backoff := h.retryInterval
+
+	for {
+		cp, err := h.FetchCheckpoint(ctx, -1)
+
+		switch {
+		case errors.Is(err, ErrNoDataYet):
+			// no checkpoint yet, wait for the first one
+		case err != nil:
+			return nil, err
+		case cp.EndBlock != nil && cp.EndBlock.Uint64() > afterEndBlock:
+			return cp, nil
+		}
+
+		if err := h.wait(ctx, backoff); err != nil {
+			return nil, err
+		}
+
+		backoff *= 2
+		if backoff > maxCheckpointPollBackoff {
+			backoff = maxCheckpointPollBackoff
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package heimdall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointsByNumbers(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var number int64
+		if _, err := fmt.Sscanf(r.URL.Path, "/checkpoints/%d", &number); err != nil || number == 3 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = fmt.Fprintf(w, `{"result":{"start_block":%d,"end_block":%d}}`, (number-1)*256, number*256-1)
+	}))
+	defer srv.Close()
+
+	client := NewHeimdallClient(srv.URL, WithRetryBudget(1))
+
+	numbers := []uint64{1, 2, 3, 4, 5, 6, 7}
+
+	checkpoints, err := client.CheckpointsByNumbers(context.Background(), numbers)
+	require.Error(t, err)
+
+	var errs CheckpointErrors
+
+	require.True(t, errors.As(err, &errs))
+	require.Len(t, errs, 1)
+	require.ErrorIs(t, errs[3], ErrNotSuccessfulResponse)
+
+	require.Len(t, checkpoints, len(numbers)-1)
+
+	for _, number := range numbers {
+		if number == 3 {
+			continue
+		}
+
+		require.Equal(t, big.NewInt(int64(number)*256-1), checkpoints[number].EndBlock)
+	}
+
+	// the numbers beyond math.MaxInt64 aren't fetched, nor is the latest checkpoint
+	invalid := []uint64{math.MaxInt64 + 1, math.MaxUint64}
+
+	checkpoints, err = client.CheckpointsByNumbers(context.Background(), invalid)
+	require.True(t, errors.As(err, &errs))
+	require.Empty(t, checkpoints)
+	require.Len(t, errs, len(invalid))
+
+	for _, err := range errs {
+		require.ErrorIs(t, err, ErrInvalidCheckpointNumber)
+	}
+
+	// nothing is fetched once the client is closed
+	client.Close()
+
+	checkpoints, err = client.CheckpointsByNumbers(context.Background(), numbers)
+	require.True(t, errors.As(err, &errs))
+	require.Empty(t, checkpoints)
+	require.Len(t, errs, len(numbers))
+
+	for _, err := range errs {
+		require.ErrorIs(t, err, ErrShutdownDetected)
+	}
+}
+
+func TestFetchCheckpointAfter(t *testing.T) {
+	t.Parallel()
+
+	var polls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		// the new checkpoint appears after a few polls of the old one
+		endBlock := 255
+		if polls.Add(1) > 3 {
+			endBlock = 511
+		}
+
+		_, _ = fmt.Fprintf(w, `{"result":{"start_block":0,"end_block":%d}}`, endBlock)
+	}))
+	defer srv.Close()
+
+	client := NewHeimdallClient(srv.URL)
+	client.retryInterval = time.Millisecond
+
+	cp, err := client.FetchCheckpointAfter(context.Background(), 255)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(511), cp.EndBlock)
+	require.Equal(t, int32(4), polls.Load())
+
+	// the wait is bounded by the context and the client
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = client.FetchCheckpointAfter(ctx, 511)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		client.Close()
+	}()
+
+	_, err = client.FetchCheckpointAfter(context.Background(), 511)
+	require.ErrorIs(t, err, ErrShutdownDetected)
+}
@@ -0,0 +1,91 @@
+package heimdall
+
+import (
+	"bytes"
+	"crypto/md5" //nolint:gosec
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrChecksumMismatch is returned if the digest of a response body doesn't match the
+// one of its Content-MD5 or Digest header
+var ErrChecksumMismatch = errors.New("heimdall response checksum mismatch")
+
+// digestAlgorithms are the supported algorithms of the Digest header, by lowercase name
+var digestAlgorithms = map[string]func() hash.Hash{
+	"md5":     md5.New,
+	"sha-256": sha256.New,
+	"sha-512": sha512.New,
+}
+
+// bodyDigests computes the digests of a response body announced by its headers
+type bodyDigests struct {
+	expected map[string][]byte
+	hashes   map[string]hash.Hash
+}
+
+// newBodyDigests returns the digests to compute for the response with the given
+// header, nil if it announces none with a supported algorithm
+func newBodyDigests(header http.Header) *bodyDigests {
+	expected := make(map[string][]byte)
+
+	if value := header.Get("Content-MD5"); value != "" {
+		if sum, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value)); err == nil {
+			expected["md5"] = sum
+		}
+	}
+
+	// e.g. Digest: SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=,MD5=...
+	for _, digest := range strings.Split(header.Get("Digest"), ",") {
+		algorithm, value, ok := strings.Cut(strings.TrimSpace(digest), "=")
+		algorithm = strings.ToLower(algorithm)
+
+		if _, supported := digestAlgorithms[algorithm]; !ok || !supported {
+			continue
+		}
+
+		if sum, err := base64.StdEncoding.DecodeString(value); err == nil {
+			expected[algorithm] = sum
+		}
+	}
+
+	if len(expected) == 0 {
+		return nil
+	}
+
+	hashes := make(map[string]hash.Hash, len(expected))
+	for algorithm := range expected {
+		hashes[algorithm] = digestAlgorithms[algorithm]()
+	}
+
+	return &bodyDigests{expected: expected, hashes: hashes}
+}
+
+// tee returns a reader computing the digests of the body read from r
+func (d *bodyDigests) tee(r io.Reader) io.Reader {
+	writers := make([]io.Writer, 0, len(d.hashes))
+	for _, h := range d.hashes {
+		writers = append(writers, h)
+	}
+
+	return io.TeeReader(r, io.MultiWriter(writers...))
+}
+
+// verify compares the digests of the body read with the announced ones
+func (d *bodyDigests) verify() error {
+	for algorithm, h := range d.hashes {
+		if sum := h.Sum(nil); !bytes.Equal(sum, d.expected[algorithm]) {
+			return fmt.Errorf("%w: %s %s, announced %s", ErrChecksumMismatch, algorithm,
+				base64.StdEncoding.EncodeToString(sum), base64.StdEncoding.EncodeToString(d.expected[algorithm]))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package heimdall
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5" //nolint:gosec
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksumVerification(t *testing.T) {
+	t.Parallel()
+
+	body := []byte(`{"height":"0","result":{"result":7}}`)
+
+	md5Sum := md5.Sum(body) //nolint:gosec
+	sha256Sum := sha256.Sum256(body)
+	tampered := sha256.Sum256([]byte("tampered"))
+
+	var gzipped bytes.Buffer
+
+	gz := gzip.NewWriter(&gzipped)
+	_, _ = gz.Write(body)
+	_ = gz.Close()
+
+	gzippedSum := sha256.Sum256(gzipped.Bytes())
+
+	tests := []struct {
+		name   string
+		header http.Header
+		body   []byte
+		opts   []Option
+		err    error
+	}{
+		{name: "no digest", header: http.Header{}},
+		{name: "content md5", header: http.Header{"Content-Md5": {base64.StdEncoding.EncodeToString(md5Sum[:])}}},
+		{name: "digest sha-256", header: http.Header{"Digest": {"SHA-256=" + base64.StdEncoding.EncodeToString(sha256Sum[:])}}},
+		{name: "unsupported algorithm", header: http.Header{"Digest": {"UNIXsum=30637"}}},
+		{name: "tampered digest", header: http.Header{"Digest": {"SHA-256=" + base64.StdEncoding.EncodeToString(tampered[:])}}, err: ErrChecksumMismatch},
+		{name: "tampered content md5", header: http.Header{"Content-Md5": {base64.StdEncoding.EncodeToString(tampered[:16])}}, err: ErrChecksumMismatch},
+		{name: "tampered digest unverified", header: http.Header{"Digest": {"SHA-256=" + base64.StdEncoding.EncodeToString(tampered[:])}}, opts: []Option{}},
+		{
+			name:   "digest of the gzipped body",
+			header: http.Header{"Content-Encoding": {"gzip"}, "Digest": {"SHA-256=" + base64.StdEncoding.EncodeToString(gzippedSum[:])}},
+			body:   gzipped.Bytes(),
+			// asking for gzip keeps the transport from decompressing the body itself
+			opts: []Option{WithChecksumVerification(), WithRequestInterceptor(func(req *http.Request) error {
+				req.Header.Set("Accept-Encoding", "gzip")
+				return nil
+			})},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				for key, values := range test.header {
+					w.Header()[key] = values
+				}
+
+				if test.body != nil {
+					_, _ = w.Write(test.body)
+				} else {
+					_, _ = w.Write(body)
+				}
+			}))
+			defer srv.Close()
+
+			opts := test.opts
+			if opts == nil {
+				opts = []Option{WithChecksumVerification()}
+			}
+
+			client := NewHeimdallClient(srv.URL, append(opts, WithRetryBudget(1))...)
+
+			count, err := client.FetchCheckpointCount(context.Background())
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, int64(7), count)
+		})
+	}
+}
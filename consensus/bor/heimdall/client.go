@@ -1,20 +1,29 @@
 package heimdall
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/bor/clerk"
 	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/checkpoint"
 	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/milestone"
 	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/span"
+	"github.com/ethereum/go-ethereum/consensus/bor/valset"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 )
@@ -26,8 +35,58 @@ var (
 	ErrNotSuccessfulResponse = errors.New("error while fetching data from Heimdall")
 	ErrNotInRejectedList     = errors.New("milestoneID doesn't exist in rejected list")
 	ErrNotInMilestoneList    = errors.New("milestoneID doesn't exist in Heimdall")
+
+	// ErrHeimdallError is returned if heimdall replies with an error envelope despite a
+	// successful status code
+	ErrHeimdallError = errors.New("heimdall returned an error")
+
+	// ErrNoDataYet is returned if heimdall has no latest checkpoint, milestone or span
+	// yet, e.g. right after genesis. It is only returned with WithNoDataYetOn404.
+	ErrNoDataYet = errors.New("no data available in heimdall yet")
+
+	// ErrInvalidHeimdallURL is returned if the heimdall url is empty, unparseable, or
+	// lacks the scheme or the host
+	ErrInvalidHeimdallURL = errors.New("invalid heimdall url")
+
+	// ErrConflictingStateSyncEvents is returned if heimdall served the same state sync
+	// event ID with different payloads
+	ErrConflictingStateSyncEvents = errors.New("conflicting state sync events with the same ID")
+
+	// ErrNoBufferedCheckpoint is returned if heimdall has no checkpoint proposed and
+	// waiting for its confirmation on the root chain
+	ErrNoBufferedCheckpoint = errors.New("no buffered checkpoint")
+
+	// ErrStateSyncBudgetExceeded is returned along with the state sync events fetched
+	// so far if the paging outlasted the budget set with WithStateSyncBudget
+	ErrStateSyncBudgetExceeded = errors.New("state sync events budget exceeded")
+
+	// ErrResponseTooLarge is returned if a compressed response decompresses to more
+	// than maxDecompressedBody bytes
+	ErrResponseTooLarge = errors.New("response too large")
 )
 
+// StatusError is returned if heimdall replies with a status code which isn't accepted
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%v: response code %d", ErrNotSuccessfulResponse, e.StatusCode)
+}
+
+func (e *StatusError) Unwrap() error {
+	return ErrNotSuccessfulResponse
+}
+
+// TimeoutKey is the context key of a time.Duration overriding the timeout of each
+// attempt of the requests made with the context, e.g.
+//
+//	ctx = context.WithValue(ctx, heimdall.TimeoutKey{}, 2*time.Second)
+//
+// It takes precedence over the policies and the timeout derived from the context
+// deadline. Non-positive durations are ignored.
+type TimeoutKey struct{}
+
 const (
 	stateFetchLimit    = 50
 	apiHeimdallTimeout = 5 * time.Second
@@ -44,25 +103,239 @@ type SpanResponse struct {
 	Result span.HeimdallSpan `json:"result"`
 }
 
+type SpanListResponse struct {
+	Height string              `json:"height"`
+	Result []span.HeimdallSpan `json:"result"`
+}
+
+// BlockResponse is the latest block of heimdall, only holding its height
+type BlockResponse struct {
+	Block struct {
+		Header struct {
+			Height json.Number `json:"height"`
+		} `json:"header"`
+	} `json:"block"`
+}
+
+// StatusResponse is the status of the heimdall node, only holding its sync info
+type StatusResponse struct {
+	Result struct {
+		SyncInfo struct {
+			LatestBlockHeight json.Number `json:"latest_block_height"`
+			CatchingUp        bool        `json:"catching_up"`
+		} `json:"sync_info"`
+	} `json:"result"`
+}
+
+// Status is the sync status reported by the heimdall node
+type Status struct {
+	LatestBlockHeight uint64
+	CatchingUp        bool
+}
+
+// ContractAddresses are the addresses of the root chain contracts, and of the bor
+// contract receiving the state syncs, from the chain manager params of heimdall
+type ContractAddresses struct {
+	RootChain      common.Address `json:"root_chain_address"`
+	StateSender    common.Address `json:"state_sender_address"`
+	StakingManager common.Address `json:"staking_manager_address"`
+	StakingInfo    common.Address `json:"staking_info_address"`
+	SlashManager   common.Address `json:"slash_manager_address"`
+	MaticToken     common.Address `json:"matic_token_address"`
+	StateReceiver  common.Address `json:"state_receiver_address"`
+	ValidatorSet   common.Address `json:"validator_set_address"`
+}
+
+// ChainManagerParamsResponse holds the chain manager params of heimdall, only the
+// contract addresses
+type ChainManagerParamsResponse struct {
+	Height string `json:"height"`
+	Result struct {
+		ChainParams ContractAddresses `json:"chain_params"`
+	} `json:"result"`
+}
+
 type HeimdallClient struct {
-	urlString string
-	client    http.Client
-	closeCh   chan struct{}
+	urlString  string
+	canaryURL  string
+	replicaURL string
+	basicAuth  *url.Userinfo
+	authHost   string // only requests to this host get the basic auth
+	client     http.Client
+	closeCh    chan struct{}
+
+	retryInterval  time.Duration
+	retryJitter    float64
+	jitterSource   func() float64
+	budgetAttempts int
+
+	defaultDeadline time.Duration
+
+	spanLength           uint64
+	spanFetchConcurrency int
+
+	genesisMu         sync.Mutex
+	genesisValidators *valset.ValidatorSet
+
+	stateSyncSpanMu sync.Mutex
+	stateSyncSpan   *span.HeimdallSpan
+
+	transportCfg    transportConfig
+	sharedTransport http.RoundTripper
+	dryRun          map[string]string
+	resolver        *urlResolver
+	defaultQuery    url.Values
+
+	requireFields  bool
+	noDataYetOn404 bool
+
+	checkpointTipCheck bool
+	maxClockSkew       time.Duration
+	partialOnShutdown  bool
+	lastStateSyncPage  StateSyncPaging
+	stateSyncBudget    time.Duration
+	stateSyncSlots     chan struct{}
+
+	acceptedStatus map[int]struct{}
+
+	breakers *circuitBreakers
+	cache    *responseCache
+	modified *modifiedCache
+	journal  *requestJournal
+	policies []pathPolicy
+	counts   *coalescer
+
+	lastCounts *staleCounts
+	regression *regressionGuard
+
+	interceptors []RequestInterceptor
+
+	metricsRegistry metrics.Registry
+	metrics         *clientMetrics
+	callerTags      map[string]struct{}
+	onDecodeFailure func(path string, sample []byte, err error)
+	onSLAViolation  func(path string, elapsed time.Duration)
+
+	slowRequestThreshold time.Duration
+	errorLogInterval     time.Duration
+	verifyChecksums      bool
+
+	fastRetryDelay    time.Duration
+	fastRetryAttempts int
+
+	immediateRetryErrors []error
+
+	poolWaitLimit time.Duration
+	decodeRetries int
+
+	adaptiveTimeout *adaptiveTimeout
 }
 
+// RequestInterceptor is called with every request before it is sent, and may modify
+// it, e.g. to sign it. An error aborts the attempt.
+type RequestInterceptor func(req *http.Request) error
+
+// RequestBuilder builds the http request sent by an attempt. The request must use
+// the given context. Failed requests are only retried if idempotent: their method
+// is, like GET or PUT but unlike POST, or they have an Idempotency-Key header.
+type RequestBuilder func(ctx context.Context) (*http.Request, error)
+
 type Request struct {
-	client http.Client
-	url    *url.URL
-	start  time.Time
+	client  http.Client
+	url     *url.URL
+	build   RequestBuilder
+	start   time.Time
+	timeout time.Duration
+
+	// idempotent requests are retried on failure
+	idempotent bool
+
+	// ifModifiedSince is the If-Modified-Since header of conditional requests
+	ifModifiedSince string
+
+	// id is sent in the X-Request-ID header, unless empty
+	id string
+
+	// resolved requests are sent to the url returned by the URLResolver
+	resolved bool
+
+	heimdall *HeimdallClient
+
+	// header and status code of the response, set once it is received
+	header http.Header
+	status int
+}
+
+func NewHeimdallClient(urlString string, opts ...Option) *HeimdallClient {
+	// each attempt is bounded by its own timeout, which a client timeout would cap
+	h := newHeimdallClient(urlString, http.Client{}, make(chan struct{}))
+
+	// keep the credentials out of the request urls, which are logged and cached
+	if u, err := url.Parse(urlString); err == nil {
+		if u.User != nil {
+			h.basicAuth = u.User
+			u.User = nil
+			h.urlString = u.String()
+		}
+
+		h.authHost = u.Host
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	switch {
+	case h.dryRun != nil:
+		h.client.Transport = dryRunTransport{responses: h.dryRun}
+	case h.sharedTransport != nil:
+		h.client.Transport = h.sharedTransport
+	default:
+		h.client.Transport = newTransport(h.transportCfg)
+	}
+	h.metrics = newClientMetrics(h.metricsRegistry)
+
+	return h
+}
+
+// NewValidatedHeimdallClient is NewHeimdallClient, failing with ErrInvalidHeimdallURL
+// upfront rather than on every request if the url is invalid.
+func NewValidatedHeimdallClient(urlString string, opts ...Option) (*HeimdallClient, error) {
+	if err := validateURL(urlString); err != nil {
+		return nil, err
+	}
+
+	return NewHeimdallClient(urlString, opts...), nil
+}
+
+func validateURL(urlString string) error {
+	if urlString == "" {
+		return fmt.Errorf("%w: empty", ErrInvalidHeimdallURL)
+	}
+
+	u, err := url.Parse(urlString)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidHeimdallURL, err)
+	}
+
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("%w: %q lacks the scheme or the host", ErrInvalidHeimdallURL, u.Redacted())
+	}
+
+	return nil
 }
 
-func NewHeimdallClient(urlString string) *HeimdallClient {
+// newHeimdallClient returns a client with the default behaviour
+func newHeimdallClient(urlString string, client http.Client, closeCh chan struct{}) *HeimdallClient {
 	return &HeimdallClient{
-		urlString: urlString,
-		client: http.Client{
-			Timeout: apiHeimdallTimeout,
-		},
-		closeCh: make(chan struct{}),
+		urlString:     urlString,
+		client:        client,
+		closeCh:       closeCh,
+		retryInterval: retryCall,
+		spanLength:    span.DefaultSpanLength,
+		metrics:       newClientMetrics(nil),
+
+		spanFetchConcurrency: defaultSpanFetchConcurrency,
 	}
 }
 
@@ -70,35 +343,127 @@ const (
 	fetchStateSyncEventsFormat = "from-id=%d&to-time=%d&limit=%d"
 	fetchStateSyncEventsPath   = "clerk/event-record/list"
 
-	fetchCheckpoint      = "/checkpoints/%s"
-	fetchCheckpointCount = "/checkpoints/count"
+	// the contract filter appended to the state sync events query
+	fetchStateSyncContractFormat = "&contract=%s"
+
+	// the first event of a time range
+	fetchFirstStateSyncEventFormat = "from-time=%d&to-time=%d&page=1&limit=1"
 
-	fetchMilestone      = "/milestone/latest"
-	fetchMilestoneCount = "/milestone/count"
+	fetchCheckpoint       = "/checkpoints/%s"
+	fetchCheckpointCount  = "/checkpoints/count"
+	fetchCheckpointBuffer = "/checkpoints/buffer"
+
+	fetchMilestone         = "/milestone/latest"
+	fetchMilestoneCount    = "/milestone/count"
+	fetchMilestoneByNumber = "/milestone/%d"
 
 	fetchLastNoAckMilestone = "/milestone/lastNoAck"
 	fetchNoAckMilestone     = "/milestone/noAck/%s"
+	fetchNoAckMilestoneList = "/milestone/noAckList"
+	fetchNoAckRangeFormat   = "from=%d&to=%d"
 	fetchMilestoneID        = "/milestone/ID/%s"
+	fetchMilestoneSigners   = "/milestone/signers/%s"
+	fetchMilestoneParams    = "/milestone/params"
+
+	fetchSpanFormat     = "bor/span/%d"
+	fetchSpanListPath   = "bor/span/list"
+	fetchSpanListFormat = "page=%d&limit=%d"
+	fetchLatestSpan     = "bor/latest-span"
+	fetchNextSpanPath   = "bor/prepare-next-span"
+	fetchNextSpanFormat = "span_id=%d&start_block=%d&chain_id=%s"
+
+	fetchLatestBlock = "/blocks/latest"
+
+	fetchStatus = "/status"
 
-	fetchSpanFormat = "bor/span/%d"
+	fetchChainManagerParams = "/chainmanager/params"
 )
 
 func (h *HeimdallClient) StateSyncEvents(ctx context.Context, fromID uint64, to int64) ([]*clerk.EventRecordWithTime, error) {
+	eventRecords, _, err := h.stateSyncEvents(ctx, fromID, to, nil, 0)
+
+	return eventRecords, err
+}
+
+// StateSyncEventsPages fetches the state sync events like StateSyncEvents, stopping
+// after maxPages pages so that the work per call is bounded. It returns the id to
+// resume fetching from, or 0 once all the events were fetched.
+func (h *HeimdallClient) StateSyncEventsPages(ctx context.Context, fromID uint64, to int64, maxPages int) ([]*clerk.EventRecordWithTime, uint64, error) {
+	return h.stateSyncEvents(ctx, fromID, to, nil, maxPages)
+}
+
+// StateSyncEventsForContract fetches the state sync events like StateSyncEvents, only
+// those emitted by the given contract. Heimdall filters them when it supports it, or
+// the client does, so that the result is the same either way. The filtered pages are
+// each followed by a request for a single event, telling whether more events follow.
+func (h *HeimdallClient) StateSyncEventsForContract(ctx context.Context, fromID uint64, to int64, contract common.Address) ([]*clerk.EventRecordWithTime, error) {
+	eventRecords, _, err := h.stateSyncEvents(ctx, fromID, to, &contract, 0)
+
+	return eventRecords, err
+}
+
+// stateSyncEvents pages through the state sync events, those of the contract if any,
+// for up to maxPages pages unless zero. It returns the id of the next page, 0 if none.
+func (h *HeimdallClient) stateSyncEvents(ctx context.Context, fromID uint64, to int64, contract *common.Address, maxPages int) ([]*clerk.EventRecordWithTime, uint64, error) {
 	eventRecords := make([]*clerk.EventRecordWithTime, 0)
 
-	for {
-		url, err := stateSyncURL(h.urlString, fromID, to)
+	if err := h.acquireStateSyncSlot(ctx); err != nil {
+		return nil, 0, err
+	}
+	defer h.releaseStateSyncSlot()
+
+	// the budget bounds all the pages and their retries
+	parent := ctx
+
+	if h.stateSyncBudget > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, h.stateSyncBudget)
+		defer cancel()
+	}
+
+	for pages := 1; ; pages++ {
+		var (
+			url *url.URL
+			err error
+		)
+
+		if contract != nil {
+			url, err = stateSyncForContractURL(h.urlString, fromID, to, *contract)
+		} else {
+			url, err = stateSyncURL(h.urlString, fromID, to)
+		}
+
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 
 		log.Info("Fetching state sync events", "queryParams", url.RawQuery)
 
 		ctx = withRequestType(ctx, stateSyncRequest)
 
-		response, err := FetchWithRetry[StateSyncEventsResponse](ctx, h.client, url, h.closeCh)
+		// fail returns the events fetched so far on a shutdown or past the budget if
+		// configured so, or the error alone
+		fail := func(err error) ([]*clerk.EventRecordWithTime, uint64, error) {
+			if h.partialOnShutdown && errors.Is(err, ErrShutdownDetected) && len(eventRecords) > 0 {
+				// conflicting events are already logged, the shutdown takes precedence
+				eventRecords, _ = sortStateSyncEvents(eventRecords)
+
+				return eventRecords, fromID, err
+			}
+
+			if h.stateSyncBudget > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) && parent.Err() == nil {
+				eventRecords, _ = sortStateSyncEvents(eventRecords)
+
+				return eventRecords, fromID, fmt.Errorf("%w: %v, from id %d: %v", ErrStateSyncBudgetExceeded, h.stateSyncBudget, fromID, err)
+			}
+
+			return nil, 0, err
+		}
+
+		response, err := fetchWithRetry[StateSyncEventsResponse](ctx, h, url)
 		if err != nil {
-			return nil, err
+			return fail(err)
 		}
 
 		if response == nil || response.Result == nil {
@@ -106,20 +471,211 @@ func (h *HeimdallClient) StateSyncEvents(ctx context.Context, fromID uint64, to
 			break
 		}
 
-		eventRecords = append(eventRecords, response.Result...)
+		// heimdall may ignore the filter, serving the events of the other contracts too
+		filtered := contract != nil
+
+		if contract == nil {
+			eventRecords = append(eventRecords, response.Result...)
+		} else {
+			for _, event := range response.Result {
+				if event.Contract == *contract {
+					eventRecords = append(eventRecords, event)
+				} else {
+					filtered = false
+				}
+			}
+		}
 
-		if len(response.Result) < stateFetchLimit {
-			break
+		if filtered {
+			// Heimdall pages by id range, so a filtered page holds fewer events than the
+			// limit, or none, once other contracts emitted some of the range. Whether
+			// events follow the range is asked with an unfiltered page of one event.
+			fromID += uint64(stateFetchLimit)
+
+			more, err := h.hasStateSyncEvent(ctx, fromID, to)
+			if err != nil {
+				return fail(err)
+			}
+
+			if !more {
+				break
+			}
+		} else {
+			lastPage := h.lastStateSyncPage
+			if lastPage == nil {
+				lastPage = StopOnShortPage
+			}
+
+			if len(response.Result) == 0 || lastPage(len(response.Result), stateFetchLimit) {
+				break
+			}
+
+			if len(response.Result) < stateFetchLimit {
+				// the ids of capped pages aren't those of a full page, the next page
+				// follows the last one
+				fromID = lastStateSyncEventID(response.Result) + 1
+			} else {
+				fromID += uint64(stateFetchLimit)
+			}
+		}
+
+		if maxPages > 0 && pages >= maxPages {
+			eventRecords, err = sortStateSyncEvents(eventRecords)
+
+			return eventRecords, fromID, err
+		}
+	}
+
+	eventRecords, err := sortStateSyncEvents(eventRecords)
+
+	return eventRecords, 0, err
+}
+
+// hasStateSyncEvent reports whether heimdall has a state sync event with the given id
+// before the time
+func (h *HeimdallClient) hasStateSyncEvent(ctx context.Context, id uint64, to int64) (bool, error) {
+	url, err := makeURL(h.urlString, fetchStateSyncEventsPath, fmt.Sprintf(fetchStateSyncEventsFormat, id, to, 1))
+	if err != nil {
+		return false, err
+	}
+
+	response, err := fetchWithRetry[StateSyncEventsResponse](ctx, h, url)
+	if err != nil {
+		return false, err
+	}
+
+	return response != nil && len(response.Result) > 0, nil
+}
+
+// acquireStateSyncSlot waits for one of the state sync operations allowed at once with
+// WithMaxStateSyncOperations, unless the context is done or the client is closed
+func (h *HeimdallClient) acquireStateSyncSlot(ctx context.Context) error {
+	if h.stateSyncSlots == nil {
+		return nil
+	}
+
+	select {
+	case h.stateSyncSlots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-h.closeCh:
+		return ErrShutdownDetected
+	}
+}
+
+func (h *HeimdallClient) releaseStateSyncSlot() {
+	if h.stateSyncSlots != nil {
+		<-h.stateSyncSlots
+	}
+}
+
+// StateSyncPaging reports whether a page of state sync events with the given number
+// of events, fetched with the given limit, is the last one
+type StateSyncPaging func(events, limit int) bool
+
+// StopOnShortPage ends the paging on a page with fewer events than the limit, the
+// default
+func StopOnShortPage(events, limit int) bool {
+	return events < limit
+}
+
+// StopOnEmptyPage ends the paging on an empty page only, for servers capping the
+// pages below the requested limit
+func StopOnEmptyPage(events, _ int) bool {
+	return events == 0
+}
+
+// lastStateSyncEventID returns the highest id of the events
+func lastStateSyncEventID(eventRecords []*clerk.EventRecordWithTime) uint64 {
+	var last uint64
+
+	for _, event := range eventRecords {
+		if event.ID > last {
+			last = event.ID
 		}
+	}
+
+	return last
+}
 
-		fromID += uint64(stateFetchLimit)
+// StateSyncEventsSince fetches the state sync events from the given time until now,
+// looking up the ID of the first one. No events and no error are returned if there
+// are none since then.
+func (h *HeimdallClient) StateSyncEventsSince(ctx context.Context, since time.Time) ([]*clerk.EventRecordWithTime, error) {
+	to := time.Now().Unix()
+
+	url, err := firstStateSyncEventURL(h.urlString, since.Unix(), to)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = withRequestType(ctx, stateSyncRequest)
+
+	response, err := fetchWithRetry[StateSyncEventsResponse](ctx, h, url)
+	if err != nil {
+		return nil, fmt.Errorf("looking up the first state sync event since %d: %w", since.Unix(), err)
+	}
+
+	if len(response.Result) == 0 {
+		return []*clerk.EventRecordWithTime{}, nil
 	}
 
+	return h.StateSyncEvents(ctx, response.Result[0].ID, to)
+}
+
+// sortStateSyncEvents sorts the events by ID and removes the duplicates
+func sortStateSyncEvents(eventRecords []*clerk.EventRecordWithTime) ([]*clerk.EventRecordWithTime, error) {
 	sort.SliceStable(eventRecords, func(i, j int) bool {
 		return eventRecords[i].ID < eventRecords[j].ID
 	})
 
-	return eventRecords, nil
+	return dedupStateSyncEvents(eventRecords)
+}
+
+// dedupStateSyncEvents removes events with repeated IDs from the sorted list, keeping
+// the first occurrence. Lagging heimdall replicas can return the same event on two
+// pages. If two events share an ID but differ in payload, the deduplicated list is
+// returned along with ErrConflictingStateSyncEvents.
+func dedupStateSyncEvents(eventRecords []*clerk.EventRecordWithTime) ([]*clerk.EventRecordWithTime, error) {
+	if len(eventRecords) < 2 {
+		return eventRecords, nil
+	}
+
+	var conflicts []uint64
+
+	deduped := eventRecords[:1]
+
+	for _, event := range eventRecords[1:] {
+		last := deduped[len(deduped)-1]
+
+		if event.ID != last.ID {
+			deduped = append(deduped, event)
+			continue
+		}
+
+		if !sameStateSyncEvent(last, event) && (len(conflicts) == 0 || conflicts[len(conflicts)-1] != event.ID) {
+			conflicts = append(conflicts, event.ID)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		log.Error("Heimdall returned conflicting state sync events", "ids", conflicts)
+
+		return deduped, fmt.Errorf("%w: ids %v", ErrConflictingStateSyncEvents, conflicts)
+	}
+
+	return deduped, nil
+}
+
+func sameStateSyncEvent(a, b *clerk.EventRecordWithTime) bool {
+	return a.ID == b.ID &&
+		a.Contract == b.Contract &&
+		bytes.Equal(a.Data, b.Data) &&
+		a.TxHash == b.TxHash &&
+		a.LogIndex == b.LogIndex &&
+		a.ChainID == b.ChainID &&
+		a.Time.Equal(b.Time)
 }
 
 func (h *HeimdallClient) Span(ctx context.Context, spanID uint64) (*span.HeimdallSpan, error) {
@@ -130,7 +686,7 @@ func (h *HeimdallClient) Span(ctx context.Context, spanID uint64) (*span.Heimdal
 
 	ctx = withRequestType(ctx, spanRequest)
 
-	response, err := FetchWithRetry[SpanResponse](ctx, h.client, url, h.closeCh)
+	response, err := fetchWithRetry[SpanResponse](ctx, h, url)
 	if err != nil {
 		return nil, err
 	}
@@ -138,113 +694,302 @@ func (h *HeimdallClient) Span(ctx context.Context, spanID uint64) (*span.Heimdal
 	return &response.Result, nil
 }
 
-// FetchCheckpoint fetches the checkpoint from heimdall
-func (h *HeimdallClient) FetchCheckpoint(ctx context.Context, number int64) (*checkpoint.Checkpoint, error) {
-	url, err := checkpointURL(h.urlString, number)
+// FetchSpanList fetches a page of the span list from heimdall, pages start at 1
+func (h *HeimdallClient) FetchSpanList(ctx context.Context, page, limit uint64) ([]span.HeimdallSpan, error) {
+	url, err := spanListURL(h.urlString, page, limit)
 	if err != nil {
 		return nil, err
 	}
 
-	ctx = withRequestType(ctx, checkpointRequest)
+	ctx = withRequestType(ctx, spanListRequest)
 
-	response, err := FetchWithRetry[checkpoint.CheckpointResponse](ctx, h.client, url, h.closeCh)
+	response, err := fetchReadOnly[SpanListResponse](ctx, h, url)
 	if err != nil {
 		return nil, err
 	}
 
-	return &response.Result, nil
+	return response.Result, nil
 }
 
-// FetchMilestone fetches the checkpoint from heimdall
-func (h *HeimdallClient) FetchMilestone(ctx context.Context) (*milestone.Milestone, error) {
-	url, err := milestoneURL(h.urlString)
+// FetchCheckpoint fetches the checkpoint from heimdall
+func (h *HeimdallClient) FetchCheckpoint(ctx context.Context, number int64) (*checkpoint.Checkpoint, error) {
+	url, err := checkpointURL(h.urlString, number)
 	if err != nil {
 		return nil, err
 	}
 
-	ctx = withRequestType(ctx, milestoneRequest)
+	ctx = withRequestType(ctx, checkpointRequest)
 
-	response, err := FetchWithRetry[milestone.MilestoneResponse](ctx, h.client, url, h.closeCh)
+	response, err := fetchWithRetry[checkpoint.CheckpointResponse](ctx, h, url)
 	if err != nil {
 		return nil, err
 	}
 
-	return &response.Result, nil
-}
+	if err := h.validateCheckpoint(&response.Result); err != nil {
+		return nil, err
+	}
 
-// FetchCheckpointCount fetches the checkpoint count from heimdall
-func (h *HeimdallClient) FetchCheckpointCount(ctx context.Context) (int64, error) {
-	url, err := checkpointCountURL(h.urlString)
-	if err != nil {
-		return 0, err
+	if err := h.checkCheckpointTip(ctx, &response.Result); err != nil {
+		return nil, err
 	}
 
-	ctx = withRequestType(ctx, checkpointCountRequest)
+	if err := crossCheck(ctx, h, url, response, sameCheckpointResponse); err != nil {
+		return nil, err
+	}
 
-	response, err := FetchWithRetry[checkpoint.CheckpointCountResponse](ctx, h.client, url, h.closeCh)
-	if err != nil {
-		return 0, err
+	if number == -1 {
+		if err := h.regression.checkCheckpoint(&response.Result); err != nil {
+			return nil, err
+		}
 	}
 
-	return response.Result.Result, nil
+	return &response.Result, nil
 }
 
-// FetchMilestoneCount fetches the milestone count from heimdall
-func (h *HeimdallClient) FetchMilestoneCount(ctx context.Context) (int64, error) {
-	url, err := milestoneCountURL(h.urlString)
-	if err != nil {
-		return 0, err
-	}
+// FetchCheckpointWithNumber fetches the checkpoint like FetchCheckpoint, and also
+// returns its number. The latest checkpoint (-1) is resolved to its number using the
+// checkpoint count first, so that the pair is consistent.
+func (h *HeimdallClient) FetchCheckpointWithNumber(ctx context.Context, number int64) (*checkpoint.Checkpoint, int64, error) {
+	if number == -1 {
+		count, err := h.FetchCheckpointCount(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
 
-	ctx = withRequestType(ctx, milestoneCountRequest)
+		number = count
+	}
 
-	response, err := FetchWithRetry[milestone.MilestoneCountResponse](ctx, h.client, url, h.closeCh)
+	cp, err := h.FetchCheckpoint(ctx, number)
 	if err != nil {
-		return 0, err
+		return nil, 0, err
 	}
 
-	return response.Result.Count, nil
+	return cp, number, nil
 }
 
-// FetchLastNoAckMilestone fetches the last no-ack-milestone from heimdall
-func (h *HeimdallClient) FetchLastNoAckMilestone(ctx context.Context) (string, error) {
-	url, err := lastNoAckMilestoneURL(h.urlString)
+// FetchCheckpointEndBlock fetches the end block of the checkpoint, decoding only that
+// field of the response. Fetch decodes the responses into any type, so that other
+// projections are fetched the same way with a struct of the needed fields. The
+// checkpoint isn't validated, as its other fields are unknown.
+func (h *HeimdallClient) FetchCheckpointEndBlock(ctx context.Context, number int64) (*big.Int, error) {
+	url, err := checkpointURL(h.urlString, number)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	ctx = withRequestType(ctx, milestoneLastNoAckRequest)
+	ctx = withRequestType(ctx, checkpointRequest)
 
-	response, err := FetchWithRetry[milestone.MilestoneLastNoAckResponse](ctx, h.client, url, h.closeCh)
+	response, err := fetchWithRetry[checkpoint.CheckpointEndBlockResponse](ctx, h, url)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return response.Result.Result, nil
+	if response.Result.EndBlock == nil {
+		return nil, missingField("end_block")
+	}
+
+	return response.Result.EndBlock, nil
 }
 
-// FetchNoAckMilestone fetches the last no-ack-milestone from heimdall
-func (h *HeimdallClient) FetchNoAckMilestone(ctx context.Context, milestoneID string) error {
-	url, err := noAckMilestoneURL(h.urlString, milestoneID)
+// FetchContractAddresses fetches the addresses of the root chain contracts from the
+// chain manager params of heimdall. Heimdall doesn't track the deposit manager.
+func (h *HeimdallClient) FetchContractAddresses(ctx context.Context) (*ContractAddresses, error) {
+	url, err := chainManagerParamsURL(h.urlString)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	ctx = withRequestType(ctx, milestoneNoAckRequest)
+	ctx = withRequestType(ctx, chainManagerParamsRequest)
 
-	response, err := FetchWithRetry[milestone.MilestoneNoAckResponse](ctx, h.client, url, h.closeCh)
+	response, err := fetchWithRetry[ChainManagerParamsResponse](ctx, h, url)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if !response.Result.Result {
-		return fmt.Errorf("%w: milestoneID %q", ErrNotInRejectedList, milestoneID)
+	addresses := response.Result.ChainParams
+
+	switch {
+	case addresses.RootChain == (common.Address{}):
+		return nil, missingField("root_chain_address")
+	case addresses.StateSender == (common.Address{}):
+		return nil, missingField("state_sender_address")
 	}
 
-	return nil
+	return &addresses, nil
 }
 
-// FetchMilestoneID fetches the bool result from Heimdal whether the ID corresponding
+// FetchBufferedCheckpoint fetches the checkpoint proposed to heimdall which isn't
+// confirmed on the root chain yet, failing with ErrNoBufferedCheckpoint if none
+func (h *HeimdallClient) FetchBufferedCheckpoint(ctx context.Context) (*checkpoint.Checkpoint, error) {
+	url, err := checkpointBufferURL(h.urlString)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = withRequestType(ctx, checkpointBufferRequest)
+
+	response, err := fetchWithRetry[checkpoint.CheckpointResponse](ctx, h, url)
+	if err != nil {
+		return nil, err
+	}
+
+	// heimdall replies with an empty checkpoint when the buffer is empty
+	if response.Result.EndBlock == nil || response.Result.EndBlock.Sign() == 0 {
+		return nil, ErrNoBufferedCheckpoint
+	}
+
+	return &response.Result, nil
+}
+
+// FetchMilestone fetches the checkpoint from heimdall
+func (h *HeimdallClient) FetchMilestone(ctx context.Context) (*milestone.Milestone, error) {
+	url, err := milestoneURL(h.urlString)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = withRequestType(ctx, milestoneRequest)
+
+	response, err := fetchWithRetry[milestone.MilestoneResponse](ctx, h, url)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.validateMilestone(&response.Result); err != nil {
+		return nil, err
+	}
+
+	if err := crossCheck(ctx, h, url, response, sameMilestoneResponse); err != nil {
+		return nil, err
+	}
+
+	if err := h.regression.checkMilestone(&response.Result); err != nil {
+		return nil, err
+	}
+
+	return &response.Result, nil
+}
+
+// FetchMilestoneByNumber fetches the milestone with the given number from heimdall
+func (h *HeimdallClient) FetchMilestoneByNumber(ctx context.Context, number int64) (*milestone.Milestone, error) {
+	url, err := milestoneByNumberURL(h.urlString, number)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = withRequestType(ctx, milestoneRequest)
+
+	response, err := fetchWithRetry[milestone.MilestoneResponse](ctx, h, url)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.validateMilestone(&response.Result); err != nil {
+		return nil, err
+	}
+
+	if err := crossCheck(ctx, h, url, response, sameMilestoneResponse); err != nil {
+		return nil, err
+	}
+
+	return &response.Result, nil
+}
+
+// FetchCheckpointCount fetches the checkpoint count from heimdall
+func (h *HeimdallClient) FetchCheckpointCount(ctx context.Context) (int64, error) {
+	return h.fetchCount(ctx, checkpointCountRequest, func(ctx context.Context) (int64, error) {
+		url, err := checkpointCountURL(h.urlString)
+		if err != nil {
+			return 0, err
+		}
+
+		ctx = withRequestType(ctx, checkpointCountRequest)
+
+		response, err := fetchReadOnly[checkpoint.CheckpointCountResponse](ctx, h, url)
+		if err != nil {
+			return 0, err
+		}
+
+		return response.Result.Result, nil
+	})
+}
+
+// FetchMilestoneCount fetches the milestone count from heimdall
+func (h *HeimdallClient) FetchMilestoneCount(ctx context.Context) (int64, error) {
+	return h.fetchCount(ctx, milestoneCountRequest, func(ctx context.Context) (int64, error) {
+		url, err := milestoneCountURL(h.urlString)
+		if err != nil {
+			return 0, err
+		}
+
+		ctx = withRequestType(ctx, milestoneCountRequest)
+
+		response, err := fetchReadOnly[milestone.MilestoneCountResponse](ctx, h, url)
+		if err != nil {
+			return 0, err
+		}
+
+		return response.Result.Count, nil
+	})
+}
+
+// FetchLastNoAckMilestone fetches the last no-ack-milestone from heimdall
+func (h *HeimdallClient) FetchLastNoAckMilestone(ctx context.Context) (string, error) {
+	url, err := lastNoAckMilestoneURL(h.urlString)
+	if err != nil {
+		return "", err
+	}
+
+	ctx = withRequestType(ctx, milestoneLastNoAckRequest)
+
+	response, err := fetchWithRetry[milestone.MilestoneLastNoAckResponse](ctx, h, url)
+	if err != nil {
+		return "", err
+	}
+
+	return response.Result.Result, nil
+}
+
+// FetchNoAckMilestone fetches the last no-ack-milestone from heimdall
+func (h *HeimdallClient) FetchNoAckMilestone(ctx context.Context, milestoneID string) error {
+	url, err := noAckMilestoneURL(h.urlString, milestoneID)
+	if err != nil {
+		return err
+	}
+
+	ctx = withRequestType(ctx, milestoneNoAckRequest)
+
+	response, err := fetchWithRetry[milestone.MilestoneNoAckResponse](ctx, h, url)
+	if err != nil {
+		return err
+	}
+
+	if !response.Result.Result {
+		return fmt.Errorf("%w: milestoneID %q", ErrNotInRejectedList, milestoneID)
+	}
+
+	return nil
+}
+
+// FetchNoAckMilestones fetches the IDs of the no-ack-milestones from heimdall whose
+// number is in the given range, both included
+func (h *HeimdallClient) FetchNoAckMilestones(ctx context.Context, from, to int64) ([]string, error) {
+	url, err := noAckMilestoneListURL(h.urlString, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = withRequestType(ctx, milestoneNoAckListRequest)
+
+	response, err := fetchWithRetry[milestone.MilestoneNoAckListResponse](ctx, h, url)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Result.Result, nil
+}
+
+// FetchMilestoneID fetches the bool result from Heimdal whether the ID corresponding
 // to the given milestone is in process in Heimdall
 func (h *HeimdallClient) FetchMilestoneID(ctx context.Context, milestoneID string) error {
 	url, err := milestoneIDURL(h.urlString, milestoneID)
@@ -252,115 +997,664 @@ func (h *HeimdallClient) FetchMilestoneID(ctx context.Context, milestoneID strin
 		return err
 	}
 
-	ctx = withRequestType(ctx, milestoneIDRequest)
+	ctx = withRequestType(ctx, milestoneIDRequest)
+
+	response, err := fetchWithRetry[milestone.MilestoneIDResponse](ctx, h, url)
+
+	if err != nil {
+		return err
+	}
+
+	if !response.Result.Result {
+		return fmt.Errorf("%w: milestoneID %q", ErrNotInMilestoneList, milestoneID)
+	}
+
+	return nil
+}
+
+// FetchMilestoneSigners fetches the validators which signed the milestone with the
+// given ID, with their voting power
+func (h *HeimdallClient) FetchMilestoneSigners(ctx context.Context, milestoneID string) ([]milestone.MilestoneSigner, error) {
+	url, err := milestoneSignersURL(h.urlString, milestoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = withRequestType(ctx, milestoneSignersRequest)
+
+	response, err := fetchWithRetry[milestone.MilestoneSignersResponse](ctx, h, url)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Result, nil
+}
+
+// FetchMilestoneParams fetches the milestone parameters, so that bor can whitelist
+// the milestones at their cadence
+func (h *HeimdallClient) FetchMilestoneParams(ctx context.Context) (*milestone.MilestoneParams, error) {
+	url, err := milestoneParamsURL(h.urlString)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = withRequestType(ctx, milestoneParamsRequest)
+
+	response, err := fetchWithRetry[milestone.MilestoneParamsResponse](ctx, h, url)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.Result, nil
+}
+
+// FetchStatus fetches the sync status of the heimdall node, with the height of its
+// latest block
+func (h *HeimdallClient) FetchStatus(ctx context.Context) (*Status, error) {
+	return h.fetchStatus(ctx, h.budgetAttempts)
+}
+
+// fetchStatus fetches the status with at most maxAttempts tries
+func (h *HeimdallClient) fetchStatus(ctx context.Context, maxAttempts int) (*Status, error) {
+	url, err := statusURL(h.urlString)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = withRequestType(ctx, statusRequest)
+
+	response, err := fetchWithRetryAttempts[StatusResponse](ctx, h, url, maxAttempts)
+	if err != nil {
+		return nil, err
+	}
+
+	height, err := strconv.ParseUint(response.Result.SyncInfo.LatestBlockHeight.String(), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parsing latest block height: %w", err)
+	}
+
+	return &Status{LatestBlockHeight: height, CatchingUp: response.Result.SyncInfo.CatchingUp}, nil
+}
+
+// FetchLatestBlock fetches the height of the latest block committed by heimdall
+func (h *HeimdallClient) FetchLatestBlock(ctx context.Context) (uint64, error) {
+	url, err := latestBlockURL(h.urlString)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx = withRequestType(ctx, latestBlockRequest)
+
+	response, err := fetchWithRetry[BlockResponse](ctx, h, url)
+	if err != nil {
+		return 0, err
+	}
+
+	height, err := strconv.ParseUint(response.Block.Header.Height.String(), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing latest block height: %w", err)
+	}
+
+	return height, nil
+}
+
+// MeasureLatency returns the round-trip time of a single, lightweight request to
+// heimdall. It isn't retried, nor served from the cache.
+func (h *HeimdallClient) MeasureLatency(ctx context.Context) (time.Duration, error) {
+	url, err := checkpointCountURL(h.urlString)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+
+	if _, err := internalFetchWithTimeout(ctx, h.newRequest(ctx, url, 1)); err != nil {
+		return 0, err
+	}
+
+	return time.Since(start), nil
+}
+
+// FetchWithRetry returns data from heimdall with retry
+func FetchWithRetry[T any](ctx context.Context, client http.Client, url *url.URL, closeCh chan struct{}) (*T, error) {
+	return fetchWithRetry[T](ctx, newHeimdallClient("", client, closeCh), url)
+}
+
+// FetchWithMaxAttempts returns data from heimdall with at most maxAttempts tries. A
+// single attempt means no retry, while zero retries until success or shutdown.
+func FetchWithMaxAttempts[T any](ctx context.Context, client http.Client, url *url.URL, closeCh chan struct{}, maxAttempts int) (*T, error) {
+	return fetchWithRetryAttempts[T](ctx, newHeimdallClient("", client, closeCh), url, maxAttempts)
+}
+
+// fetchWithRetry returns data from heimdall with retry, using the client options
+func fetchWithRetry[T any](ctx context.Context, h *HeimdallClient, url *url.URL) (*T, error) {
+	return fetchWithRetryAttempts[T](ctx, h, url, h.budgetAttempts)
+}
+
+// FetchWithRequestBuilder returns data from heimdall with retry, sending the request
+// built anew by build on each attempt. It serves requests other than plain GETs of
+// an url, like ones with custom headers or bodies.
+func FetchWithRequestBuilder[T any](ctx context.Context, client http.Client, build RequestBuilder, closeCh chan struct{}) (*T, error) {
+	return fetchWithRequestBuilder[T](ctx, newHeimdallClient("", client, closeCh), build)
+}
+
+// fetchWithRequestBuilder returns data from heimdall with retry, using the client
+// options and the built requests
+func fetchWithRequestBuilder[T any](ctx context.Context, h *HeimdallClient, build RequestBuilder) (*T, error) {
+	ctx, cancel := h.withDefaultDeadline(ctx)
+	defer cancel()
+
+	return retryFetch[T](ctx, h, h.budgetAttempts, func(attempt int) *Request {
+		request := h.newRequest(ctx, nil, attempt)
+		request.build = build
+
+		return request
+	})
+}
+
+// fetchWithRetryAttempts returns data from heimdall with at most maxAttempts tries,
+// or unbounded retries if maxAttempts is zero
+func fetchWithRetryAttempts[T any](ctx context.Context, h *HeimdallClient, url *url.URL, maxAttempts int) (*T, error) {
+	ctx, cancel := h.withDefaultDeadline(ctx)
+	defer cancel()
+
+	return retryFetch[T](ctx, h, maxAttempts, func(attempt int) *Request {
+		return h.newRequest(ctx, url, attempt)
+	})
+}
+
+// withDefaultDeadline applies the deadline set with WithDefaultDeadline to contexts
+// without one
+func (h *HeimdallClient) withDefaultDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if h.defaultDeadline <= 0 {
+		return ctx, func() {}
+	}
+
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, h.defaultDeadline)
+}
+
+// retryFetch fetches the requests created by newRequest for each attempt, until one
+// succeeds or maxAttempts is reached
+func retryFetch[T any](ctx context.Context, h *HeimdallClient, maxAttempts int, newRequest func(attempt int) *Request) (*T, error) {
+	// no request is sent once the client is closed
+	select {
+	case <-h.closeCh:
+		return nil, ErrShutdownDetected
+	default:
+	}
+
+	// attempt counter
+	attempt := 1
+
+	defer func() { recordAttempts(ctx, attempt) }()
+
+	// request data once
+	request := newRequest(attempt)
+	result, err := Fetch[T](ctx, request)
+
+	if err == nil {
+		return result, nil
+	}
+
+	// the decode failures retried if configured with WithDecodeRetries
+	decodeFailures := 0
+
+	// stop reports whether the error of the request is returned without retrying
+	stop := func(request *Request, err error) bool {
+		return isPermanentError(err) || !request.idempotent || h.decodeRetriesExhausted(err, &decodeFailures)
+	}
+
+	if stop(request, err) {
+		return nil, err
+	}
+
+	// repeated errors are logged once if configured with WithRepeatedErrorSuppression
+	var errLog *retryLogger
+
+	if h.errorLogInterval > 0 {
+		errLog = &retryLogger{path: request.path(), interval: h.errorLogInterval}
+		errLog.log(attempt, err)
+
+		defer func() { errLog.done(attempt, err) }()
+	} else {
+		log.Warn("an error while trying fetching from Heimdall", "attempt", attempt, "error", err)
+	}
+
+	policy := h.PolicyFor(request.path())
+	if policy.MaxAttempts > 0 && (maxAttempts <= 0 || policy.MaxAttempts < maxAttempts) {
+		maxAttempts = policy.MaxAttempts
+	}
+
+	if bound, ok := ctx.Value(maxAttemptsKey{}).(int); ok && (maxAttempts <= 0 || bound < maxAttempts) {
+		maxAttempts = bound
+	}
+
+	// errors like a reset of a reused keep-alive connection are retried right away
+	// once if configured with WithImmediateRetry
+	if h.isImmediateRetryError(err) && (maxAttempts <= 0 || attempt < maxAttempts) {
+		attempt++
+		request = newRequest(attempt)
+
+		result, err = Fetch[T](ctx, request)
+		if err == nil {
+			return result, nil
+		}
+
+		if stop(request, err) {
+			return nil, err
+		}
+	}
+
+	// the first failures answered by the server are retried sooner if configured with
+	// WithFastRetry
+	fastRetries := 0
+
+	nextDelay := func(err error) time.Duration {
+		if fastRetries < h.fastRetryAttempts && isServerError(err) {
+			fastRetries++
+			return h.fastRetryDelay
+		}
+
+		return h.retryDelay(policy.RetryInterval)
+	}
+
+	// create a new timer for retrying the request. It's reset once each attempt is
+	// done, so that the interval is measured from the end of the previous attempt
+	// however long it took.
+	delay := nextDelay(err)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	const logEach = 5
+
+retryLoop:
+	for {
+		if maxAttempts > 0 && attempt >= maxAttempts {
+			log.Warn("Retry attempts exhausted while fetching data from Heimdall", "path", request.path(), "attempts", attempt)
+
+			return nil, err
+		}
+
+		log.Info("Retrying again to fetch data from Heimdall", "path", request.path(), "attempt", attempt, "in", delay)
+
+		select {
+		case <-ctx.Done():
+			log.Debug("Shutdown detected, terminating request by context.Done")
+
+			return nil, ctx.Err()
+		case <-h.closeCh:
+			log.Debug("Shutdown detected, terminating request by closing")
+
+			return nil, ErrShutdownDetected
+		case <-timer.C:
+			attempt++
+			request = newRequest(attempt)
+			result, err = Fetch[T](ctx, request)
+			delay = nextDelay(err)
+			timer.Reset(delay)
+
+			if err != nil && stop(request, err) {
+				return nil, err
+			}
+
+			if err != nil {
+				if errLog != nil {
+					errLog.log(attempt, err)
+				} else if attempt%logEach == 0 {
+					log.Warn("an error while trying fetching from Heimdall", "attempt", attempt, "error", err)
+				}
+
+				continue retryLoop
+			}
+
+			return result, nil
+		}
+	}
+}
+
+// isImmediateRetryError reports whether the error is one of those retried right away
+func (h *HeimdallClient) isImmediateRetryError(err error) bool {
+	for _, target := range h.immediateRetryErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isServerError reports whether the error is a 5xx response status
+func isServerError(err error) bool {
+	var statusErr *StatusError
+
+	return errors.As(err, &statusErr) && statusErr.StatusCode >= 500
+}
+
+// isPermanentError reports whether the error is returned without retrying
+func isPermanentError(err error) bool {
+	return errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrNoDataYet) || errors.Is(err, ErrPoolExhausted)
+}
+
+// newRequest creates the request for the given attempt
+func (h *HeimdallClient) newRequest(ctx context.Context, url *url.URL, attempt int) *Request {
+	var path string
+	if url != nil {
+		path = url.Path
+	}
+
+	url = h.withDefaultQuery(url)
+	url, resolved := h.resolveURL(ctx, url)
+
+	return &Request{
+		client:  h.client,
+		url:     url,
+		start:   time.Now(),
+		timeout: h.attemptTimeout(ctx, attempt, h.PolicyFor(path)),
+
+		idempotent: true,
+		id:         newRequestID(),
+		resolved:   resolved,
+
+		heimdall: h,
+	}
+}
+
+// retryDelay returns the wait before the next attempt, the retry interval randomly
+// spread by the configured jitter fraction in both directions
+func (h *HeimdallClient) retryDelay(interval time.Duration) time.Duration {
+	if h.retryJitter <= 0 {
+		return interval
+	}
+
+	random := rand.Float64
+	if h.jitterSource != nil {
+		random = h.jitterSource
+	}
+
+	spread := h.retryJitter * (2*random() - 1)
+
+	return interval + time.Duration(spread*float64(interval))
+}
+
+// attemptTimeout returns the timeout for the given attempt of the policy. With bounded
+// attempts and a context deadline, the time left (minus the waits between the
+// remaining attempts) is split evenly across the remaining attempts, so that all of
+// them fit in the deadline. If the waits don't all fit, it is split across the
+// attempts which do.
+func (h *HeimdallClient) attemptTimeout(ctx context.Context, attempt int, policy Policy) time.Duration {
+	if policy.MaxAttempts <= 0 {
+		return policy.Timeout
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return policy.Timeout
+	}
+
+	left := policy.MaxAttempts - attempt + 1
+	if left < 1 {
+		left = 1
+	}
+
+	remaining := time.Until(deadline)
+
+	// the attempts whose waits don't fit in the time left are never made
+	for left > 1 && time.Duration(left-1)*policy.RetryInterval >= remaining {
+		left--
+	}
+
+	budget := remaining - time.Duration(left-1)*policy.RetryInterval
+
+	timeout := budget / time.Duration(left)
+	if timeout > policy.Timeout {
+		return policy.Timeout
+	}
+
+	return timeout
+}
+
+// Fetch returns data from heimdall
+func Fetch[T any](ctx context.Context, request *Request) (*T, error) {
+	isSuccessful := false
+
+	defer func() {
+		if metrics.EnabledExpensive {
+			sendMetrics(ctx, request.start, isSuccessful)
+		}
+
+		if request.heimdall != nil {
+			request.heimdall.sendCallerMetrics(ctx, request.start, isSuccessful)
+		}
+	}()
+
+	result := new(T)
+
+	var (
+		body []byte
+		err  error
+	)
+
+	if request.heimdall != nil {
+		body, err = request.heimdall.fetchBody(ctx, request)
+	} else {
+		body, err = internalFetchWithTimeout(ctx, request)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if body == nil {
+		return nil, ErrNoResponse
+	}
+
+	decodeStart := time.Now()
+
+	err = json.Unmarshal(body, result)
+
+	if request.heimdall != nil {
+		request.heimdall.sendDecodeMetrics(ctx, decodeStart)
+	}
+
+	if err != nil {
+		if request.heimdall != nil {
+			request.heimdall.decodeFailed(request.url, body, err)
+		}
+
+		return nil, newDecodeError(err, body)
+	}
+
+	isSuccessful = true
+
+	return result, nil
+}
+
+// fetchBody returns the response body for the request, going through the optional
+// response cache and circuit breaker of the client
+func (h *HeimdallClient) fetchBody(ctx context.Context, request *Request) ([]byte, error) {
+	// built requests may not be plain GETs, which are the only ones cached
+	cache := h.cache
+	if request.build != nil {
+		cache = nil
+	}
+
+	var key string
 
-	response, err := FetchWithRetry[milestone.MilestoneIDResponse](ctx, h.client, url, h.closeCh)
+	if cache != nil {
+		key = request.url.String()
 
-	if err != nil {
-		return err
+		if body, ok := cache.get(key); ok {
+			return body, nil
+		}
 	}
 
-	if !response.Result.Result {
-		return fmt.Errorf("%w: milestoneID %q", ErrNotInMilestoneList, milestoneID)
+	// the latest endpoints are fetched conditionally if enabled with WithIfModifiedSince
+	modified := h.modified
+	if request.build != nil || !isLatestPath(request.path()) {
+		modified = nil
 	}
 
-	return nil
-}
+	var last modifiedEntry
 
-// FetchWithRetry returns data from heimdall with retry
-func FetchWithRetry[T any](ctx context.Context, client http.Client, url *url.URL, closeCh chan struct{}) (*T, error) {
-	// request data once
-	request := &Request{client: client, url: url, start: time.Now()}
-	result, err := Fetch[T](ctx, request)
+	if modified != nil {
+		key = request.url.String()
 
-	if err == nil {
-		return result, nil
+		var ok bool
+		if last, ok = modified.get(key); ok {
+			request.ifModifiedSince = last.lastModified
+		}
 	}
 
-	// attempt counter
-	attempt := 1
+	var breaker *circuitBreaker
 
-	log.Warn("an error while trying fetching from Heimdall", "attempt", attempt, "error", err)
+	if h.breakers != nil {
+		breaker = h.breakers.get(ctx, request.path())
 
-	// create a new ticker for retrying the request
-	ticker := time.NewTicker(retryCall)
-	defer ticker.Stop()
+		if !breaker.allow() {
+			return nil, fmt.Errorf("%w: path %s", ErrCircuitOpen, request.path())
+		}
+	}
 
-	const logEach = 5
+	h.metrics.inFlight.Inc(1)
 
-retryLoop:
-	for {
-		log.Info("Retrying again in 5 seconds to fetch data from Heimdall", "path", url.Path, "attempt", attempt)
+	start := time.Now()
+	body, err := internalFetchWithTimeout(ctx, request)
+	elapsed := time.Since(start)
 
-		attempt++
+	h.metrics.inFlight.Dec(1)
 
-		select {
-		case <-ctx.Done():
-			log.Debug("Shutdown detected, terminating request by context.Done")
+	recordHeader(ctx, request.header)
 
-			return nil, ctx.Err()
-		case <-closeCh:
-			log.Debug("Shutdown detected, terminating request by closing")
+	if modified != nil {
+		switch {
+		case errors.Is(err, errNotModified):
+			body, err = last.body, nil
+		case err == nil:
+			modified.put(key, request.header.Get("Last-Modified"), body)
+		}
+	}
 
-			return nil, ErrShutdownDetected
-		case <-ticker.C:
-			request = &Request{client: client, url: url, start: time.Now()}
-			result, err = Fetch[T](ctx, request)
+	if err == nil {
+		recordServed(ctx, request.url)
+	}
 
-			if err != nil {
-				if attempt%logEach == 0 {
-					log.Warn("an error while trying fetching from Heimdall", "attempt", attempt, "error", err)
-				}
+	if h.onSLAViolation != nil {
+		if sla := h.PolicyFor(request.path()).SLA; sla > 0 && elapsed > sla {
+			h.onSLAViolation(request.path(), elapsed)
+		}
+	}
 
-				continue retryLoop
-			}
+	if h.slowRequestThreshold > 0 && elapsed > h.slowRequestThreshold {
+		log.Warn("Slow Heimdall request", "path", request.path(), "requestID", request.id, "elapsed", elapsed, "threshold", h.slowRequestThreshold)
+	}
 
-			return result, nil
-		}
+	if h.noDataYetOn404 && isLatestPath(request.path()) && request.status == http.StatusNotFound {
+		err = fmt.Errorf("%w: path %s", ErrNoDataYet, request.path())
 	}
-}
 
-// Fetch returns data from heimdall
-func Fetch[T any](ctx context.Context, request *Request) (*T, error) {
-	isSuccessful := false
+	if h.journal != nil {
+		h.journal.add(RequestRecord{
+			ID:         request.id,
+			Path:       request.path(),
+			StatusCode: request.status,
+			Duration:   elapsed,
+			Err:        err,
+			Time:       start,
+		})
+	}
 
-	defer func() {
-		if metrics.EnabledExpensive {
-			sendMetrics(ctx, request.start, isSuccessful)
+	// a cancelled caller says nothing about the health of the endpoint, but must not
+	// hold the probe of a half-open breaker
+	if breaker != nil {
+		if ctx.Err() == nil {
+			breaker.record(err == nil || errors.Is(err, ErrNoDataYet))
+		} else {
+			breaker.release()
 		}
-	}()
-
-	result := new(T)
+	}
 
-	body, err := internalFetchWithTimeout(ctx, request.client, request.url)
-	if err != nil {
-		return nil, err
+	if err == nil && body != nil && cache != nil {
+		cache.put(key, body, request.header)
 	}
 
-	if body == nil {
-		return nil, ErrNoResponse
+	return body, err
+}
+
+// decodeSampleSize is the size of the body sample passed to the decode failure hook
+const decodeSampleSize = 256
+
+// decodeFailed records a response body which couldn't be decoded, and evicts it from
+// the caches so that the retries fetch it anew
+func (h *HeimdallClient) decodeFailed(u *url.URL, body []byte, err error) {
+	h.metrics.decodeFailures.Inc(1)
+
+	if h.cache != nil {
+		h.cache.delete(u.String())
 	}
 
-	err = json.Unmarshal(body, result)
-	if err != nil {
-		return nil, err
+	// without a Last-Modified header, the last body is forgotten
+	if h.modified != nil {
+		h.modified.put(u.String(), "", nil)
 	}
 
-	isSuccessful = true
+	log.Warn("Failed to decode Heimdall response", "path", u.Path, "error", err)
 
-	return result, nil
+	if h.onDecodeFailure != nil {
+		if len(body) > decodeSampleSize {
+			body = body[:decodeSampleSize]
+		}
+
+		h.onDecodeFailure(u.Path, body, err)
+	}
 }
 
 func spanURL(urlString string, spanID uint64) (*url.URL, error) {
 	return makeURL(urlString, fmt.Sprintf(fetchSpanFormat, spanID), "")
 }
 
+func spanListURL(urlString string, page, limit uint64) (*url.URL, error) {
+	return makeURL(urlString, fetchSpanListPath, fmt.Sprintf(fetchSpanListFormat, page, limit))
+}
+
+// isLatestPath reports whether the path is of a latest checkpoint, milestone or span
+func isLatestPath(path string) bool {
+	switch strings.TrimPrefix(path, "/") {
+	case strings.TrimPrefix(fmt.Sprintf(fetchCheckpoint, "latest"), "/"), strings.TrimPrefix(fetchMilestone, "/"), fetchLatestSpan:
+		return true
+	default:
+		return false
+	}
+}
+
+func latestSpanURL(urlString string) (*url.URL, error) {
+	return makeURL(urlString, fetchLatestSpan, "")
+}
+
+func nextSpanURL(urlString string, spanID, startBlock uint64, chainID string) (*url.URL, error) {
+	return makeURL(urlString, fetchNextSpanPath, fmt.Sprintf(fetchNextSpanFormat, spanID, startBlock, url.QueryEscape(chainID)))
+}
+
+func firstStateSyncEventURL(urlString string, from, to int64) (*url.URL, error) {
+	return makeURL(urlString, fetchStateSyncEventsPath, fmt.Sprintf(fetchFirstStateSyncEventFormat, from, to))
+}
+
 func stateSyncURL(urlString string, fromID uint64, to int64) (*url.URL, error) {
 	queryParams := fmt.Sprintf(fetchStateSyncEventsFormat, fromID, to, stateFetchLimit)
 
 	return makeURL(urlString, fetchStateSyncEventsPath, queryParams)
 }
 
+func stateSyncForContractURL(urlString string, fromID uint64, to int64, contract common.Address) (*url.URL, error) {
+	queryParams := fmt.Sprintf(fetchStateSyncEventsFormat+fetchStateSyncContractFormat, fromID, to, stateFetchLimit, contract.Hex())
+
+	return makeURL(urlString, fetchStateSyncEventsPath, queryParams)
+}
+
 func checkpointURL(urlString string, number int64) (*url.URL, error) {
 	url := ""
 	if number == -1 {
@@ -378,6 +1672,14 @@ func milestoneURL(urlString string) (*url.URL, error) {
 	return makeURL(urlString, url, "")
 }
 
+func milestoneByNumberURL(urlString string, number int64) (*url.URL, error) {
+	return makeURL(urlString, fmt.Sprintf(fetchMilestoneByNumber, number), "")
+}
+
+func checkpointBufferURL(urlString string) (*url.URL, error) {
+	return makeURL(urlString, fetchCheckpointBuffer, "")
+}
+
 func checkpointCountURL(urlString string) (*url.URL, error) {
 	return makeURL(urlString, fetchCheckpointCount, "")
 }
@@ -395,11 +1697,36 @@ func noAckMilestoneURL(urlString string, id string) (*url.URL, error) {
 	return makeURL(urlString, url, "")
 }
 
+func noAckMilestoneListURL(urlString string, from, to int64) (*url.URL, error) {
+	return makeURL(urlString, fetchNoAckMilestoneList, fmt.Sprintf(fetchNoAckRangeFormat, from, to))
+}
+
 func milestoneIDURL(urlString string, id string) (*url.URL, error) {
 	url := fmt.Sprintf(fetchMilestoneID, id)
 	return makeURL(urlString, url, "")
 }
 
+func milestoneSignersURL(urlString string, id string) (*url.URL, error) {
+	url := fmt.Sprintf(fetchMilestoneSigners, id)
+	return makeURL(urlString, url, "")
+}
+
+func milestoneParamsURL(urlString string) (*url.URL, error) {
+	return makeURL(urlString, fetchMilestoneParams, "")
+}
+
+func latestBlockURL(urlString string) (*url.URL, error) {
+	return makeURL(urlString, fetchLatestBlock, "")
+}
+
+func statusURL(urlString string) (*url.URL, error) {
+	return makeURL(urlString, fetchStatus, "")
+}
+
+func chainManagerParamsURL(urlString string) (*url.URL, error) {
+	return makeURL(urlString, fetchChainManagerParams, "")
+}
+
 func makeURL(urlString, rawPath, rawQuery string) (*url.URL, error) {
 	u, err := url.Parse(urlString)
 	if err != nil {
@@ -412,23 +1739,147 @@ func makeURL(urlString, rawPath, rawQuery string) (*url.URL, error) {
 	return u, err
 }
 
+// withDefaultQuery returns the url with the default query parameters set with
+// WithDefaultQueryParams added, unless already set by the url of the endpoint
+func (h *HeimdallClient) withDefaultQuery(u *url.URL) *url.URL {
+	if len(h.defaultQuery) == 0 || u == nil {
+		return u
+	}
+
+	query := u.Query()
+
+	for key, values := range h.defaultQuery {
+		if _, ok := query[key]; !ok {
+			query[key] = values
+		}
+	}
+
+	merged := *u
+	merged.RawQuery = query.Encode()
+
+	return &merged
+}
+
+// statusAccepted reports whether the response status code is a success
+func (r *Request) statusAccepted(code int) bool {
+	if r.heimdall == nil {
+		return code == 200 || code == 204
+	}
+
+	for _, accepted := range r.heimdall.PolicyFor(r.path()).AcceptedStatusCodes {
+		if code == accepted {
+			return true
+		}
+	}
+
+	return false
+}
+
+// path returns the url path of the request, once known
+func (r *Request) path() string {
+	if r.url == nil {
+		return ""
+	}
+
+	return r.url.Path
+}
+
+// httpRequest returns the http request to send, a GET of the request url unless it
+// has a builder
+func (r *Request) httpRequest(ctx context.Context) (*http.Request, error) {
+	if r.build == nil {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if h := r.heimdall; h != nil && h.basicAuth != nil && (r.resolved || req.URL.Host == h.authHost) {
+			password, _ := h.basicAuth.Password()
+			req.SetBasicAuth(h.basicAuth.Username(), password)
+		}
+
+		if r.ifModifiedSince != "" {
+			req.Header.Set("If-Modified-Since", r.ifModifiedSince)
+		}
+
+		if r.id != "" {
+			req.Header.Set(requestIDHeader, r.id)
+		}
+
+		return req, nil
+	}
+
+	req, err := r.build(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the builder may set its own id
+	if r.id != "" && req.Header.Get(requestIDHeader) == "" {
+		req.Header.Set(requestIDHeader, r.id)
+	}
+
+	r.url = req.URL
+	r.idempotent = isIdempotent(req)
+
+	return req, nil
+}
+
+// isIdempotent reports whether the request can be sent again without duplicating
+// its side effects, following the same rules as the http transport
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	_, hasKey := req.Header["Idempotency-Key"]
+	_, hasXKey := req.Header["X-Idempotency-Key"]
+
+	return hasKey || hasXKey
+}
+
 // internal fetch method
-func internalFetch(ctx context.Context, client http.Client, u *url.URL) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+func internalFetch(ctx context.Context, request *Request) (body []byte, err error) {
+	req, err := request.httpRequest(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := client.Do(req)
+	if request.heimdall != nil {
+		for _, intercept := range request.heimdall.interceptors {
+			if err := intercept(req); err != nil {
+				return nil, fmt.Errorf("intercepting request: %w", err)
+			}
+		}
+	}
+
+	if request.heimdall != nil {
+		var trace *connWaitTrace
+
+		req, trace = request.heimdall.traceConnWait(req)
+		defer trace.stop()
+
+		defer func() { err = trace.err(err) }()
+	}
+
+	res, err := request.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 
 	defer res.Body.Close()
 
+	request.header = res.Header
+	request.status = res.StatusCode
+
+	if res.StatusCode == http.StatusNotModified && request.ifModifiedSince != "" {
+		return nil, errNotModified
+	}
+
 	// check status code
-	if res.StatusCode != 200 && res.StatusCode != 204 {
-		return nil, fmt.Errorf("%w: response code %d", ErrNotSuccessfulResponse, res.StatusCode)
+	if !request.statusAccepted(res.StatusCode) {
+		return nil, &StatusError{StatusCode: res.StatusCode}
 	}
 
 	// unmarshall data from buffer
@@ -436,25 +1887,237 @@ func internalFetch(ctx context.Context, client http.Client, u *url.URL) ([]byte,
 		return nil, nil
 	}
 
+	reader := io.Reader(res.Body)
+	contentLength := res.ContentLength
+
+	// the digests are of the body as sent, unavailable once decompressed by the transport
+	var digests *bodyDigests
+
+	raw := reader
+	if request.heimdall != nil && request.heimdall.verifyChecksums && !res.Uncompressed {
+		if digests = newBodyDigests(res.Header); digests != nil {
+			raw = digests.tee(reader)
+			reader = raw
+		}
+	}
+
+	// a compressed body is bounded once decompressed, by the transport or below
+	decompressed := res.Uncompressed
+
+	// the transport only decompresses the responses to the requests it compressed,
+	// not those asking for gzip themselves or gzipped by a proxy regardless
+	if !res.Uncompressed && strings.EqualFold(res.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		defer gz.Close()
+
+		reader = gz
+		contentLength = -1 // the length of the compressed body
+		decompressed = true
+	}
+
+	if decompressed {
+		reader = io.LimitReader(reader, maxDecompressedBody+1)
+	}
+
 	// get response
-	body, err := io.ReadAll(res.Body)
+	body, err = readBody(reader, contentLength)
 	if err != nil {
 		return nil, err
 	}
 
+	if decompressed && len(body) > maxDecompressedBody {
+		return nil, fmt.Errorf("%w: more than %d bytes decompressed", ErrResponseTooLarge, maxDecompressedBody)
+	}
+
+	if digests != nil {
+		// the gzip reader may stop before the end of the stream
+		if _, err := io.Copy(io.Discard, raw); err != nil {
+			return nil, err
+		}
+
+		if err := digests.verify(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := envelopeError(body); err != nil {
+		return nil, err
+	}
+
 	return body, nil
 }
 
-func internalFetchWithTimeout(ctx context.Context, client http.Client, url *url.URL) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(ctx, apiHeimdallTimeout)
+// maxDecompressedBody is the largest body a compressed response may decompress to
+const maxDecompressedBody = 32 * 1024 * 1024
+
+// maxPresizedBody is the largest content length for which readBody allocates the
+// whole body upfront
+const maxPresizedBody = 64 * 1024
+
+// maxPooledBuffer is the largest buffer kept in the pool once a body was read
+const maxPooledBuffer = 1024 * 1024
+
+// bodyBuffers are the buffers the bodies of unknown length are read into
+var bodyBuffers = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// readBody reads the body of the given content length, -1 if unknown. Small bodies of
+// a known length, like the frequent counts, are read into a single allocation of
+// their size, instead of the buffer growing from 512 bytes with io.ReadAll. Bodies of
+// unknown length, like the gzipped ones, are read into a pooled buffer. Bodies longer
+// than announced are still read whole.
+func readBody(r io.Reader, contentLength int64) ([]byte, error) {
+	if contentLength <= 0 {
+		return readPooledBody(r)
+	}
+
+	if contentLength > maxPresizedBody {
+		return io.ReadAll(r)
+	}
+
+	// one spare byte to probe for data beyond the content length
+	body := make([]byte, contentLength, contentLength+1)
+
+	// read until the body is full or the reader ends, like io.ReadAll
+	for n := 0; n < len(body); {
+		read, err := r.Read(body[n:])
+		n += read
+
+		if err == io.EOF {
+			return body[:n], nil
+		}
+
+		if err != nil {
+			return body[:n], err
+		}
+	}
+
+	// the transport enforces the content length, but other readers may not
+	for {
+		read, err := r.Read(body[len(body):cap(body)])
+		if read > 0 {
+			rest, err := io.ReadAll(r)
+			return append(body[:cap(body)], rest...), err
+		}
+
+		if err == io.EOF {
+			return body, nil
+		}
+
+		if err != nil {
+			return body, err
+		}
+	}
+}
+
+// readPooledBody reads the body into a pooled buffer, grown by the previous bodies
+// already, and copies it out since the bodies outlive the read, kept by the response
+// cache and the raw fetch methods
+func readPooledBody(r io.Reader) ([]byte, error) {
+	buf := bodyBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+
+	defer func() {
+		if buf.Cap() <= maxPooledBuffer {
+			bodyBuffers.Put(buf)
+		}
+	}()
+
+	_, err := buf.ReadFrom(r)
+
+	body := make([]byte, buf.Len())
+	copy(body, buf.Bytes())
+
+	return body, err
+}
+
+// envelopeError returns the error of an {"error": ...} response envelope, which some
+// heimdall endpoints reply with instead of a failing status code
+func envelopeError(body []byte) error {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || body[0] != '{' {
+		return nil
+	}
+
+	var envelope struct {
+		Error json.RawMessage `json:"error"`
+	}
+
+	if err := json.Unmarshal(body, &envelope); err != nil || len(envelope.Error) == 0 || string(envelope.Error) == "null" {
+		return nil
+	}
+
+	var message string
+	if err := json.Unmarshal(envelope.Error, &message); err != nil {
+		// not a string, e.g. an object with a code and a message
+		message = string(envelope.Error)
+	}
+
+	if message == "" {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrHeimdallError, message)
+}
+
+func internalFetchWithTimeout(ctx context.Context, request *Request) ([]byte, error) {
+	timeout := request.timeout
+	if timeout <= 0 {
+		timeout = apiHeimdallTimeout
+	}
+
+	var adaptive *adaptiveTimeout
+	if request.heimdall != nil {
+		adaptive = request.heimdall.adaptiveTimeout
+	}
+
+	if override, ok := ctx.Value(TimeoutKey{}).(time.Duration); ok && override > 0 {
+		timeout = override
+		adaptive = nil
+	} else if adaptive != nil {
+		timeout = adaptive.timeout(timeout)
+	}
+
+	callerCtx := ctx
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	start := time.Now()
+
 	// request data once
-	return internalFetch(ctx, client, url)
+	body, err := internalFetch(ctx, request)
+
+	// attempts cut by the timeout count as that slow, so that it grows back when the
+	// latency rises
+	if adaptive != nil {
+		switch {
+		case err == nil:
+			adaptive.observe(time.Since(start))
+		case errors.Is(ctx.Err(), context.DeadlineExceeded) && callerCtx.Err() == nil:
+			adaptive.observe(timeout)
+		}
+	}
+
+	// explain failures caused by our timeout being shorter than the caller's deadline
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) && callerCtx.Err() == nil {
+		if deadline, ok := callerCtx.Deadline(); ok {
+			log.Warn("Heimdall request truncated by client timeout", "path", request.path(), "timeout", timeout, "callerRemaining", time.Until(deadline))
+		}
+	}
+
+	return body, err
 }
 
 // Close sends a signal to stop the running process
 func (h *HeimdallClient) Close() {
 	close(h.closeCh)
-	h.client.CloseIdleConnections()
+
+	// the idle connections of a shared transport may serve other clients
+	if h.sharedTransport == nil {
+		h.client.CloseIdleConnections()
+	}
 }
@@ -0,0 +1,102 @@
+package heimdall
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// coalescer batches the calls arriving within a window into a single fetch, whose
+// result is shared by all of them. Unlike a singleflight, calls slightly offset in
+// time are batched too.
+type coalescer struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	batches map[requestType]*countBatch
+}
+
+type countBatch struct {
+	done  chan struct{}
+	count int64
+	err   error
+}
+
+func newCoalescer(window time.Duration) *coalescer {
+	return &coalescer{
+		window:  window,
+		batches: make(map[requestType]*countBatch),
+	}
+}
+
+// do joins the open batch of the given key, or opens one. The caller opening it waits
+// for the window to pass and fetches with its own context.
+func (c *coalescer) do(ctx context.Context, key requestType, fetch func(ctx context.Context) (int64, error)) (int64, error) {
+	for {
+		c.mu.Lock()
+		batch, ok := c.batches[key]
+
+		if !ok {
+			batch = &countBatch{done: make(chan struct{})}
+			c.batches[key] = batch
+			c.mu.Unlock()
+
+			c.run(ctx, key, batch, fetch)
+
+			return batch.count, batch.err
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-batch.done:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+
+		// the context of the caller who fetched was done, but ours isn't
+		if isContextError(batch.err) && ctx.Err() == nil {
+			continue
+		}
+
+		return batch.count, batch.err
+	}
+}
+
+func (c *coalescer) run(ctx context.Context, key requestType, batch *countBatch, fetch func(ctx context.Context) (int64, error)) {
+	defer close(batch.done)
+
+	timer := time.NewTimer(c.window)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	// close the window, later calls open a new batch
+	c.mu.Lock()
+	delete(c.batches, key)
+	c.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		batch.err = err
+		return
+	}
+
+	batch.count, batch.err = fetch(ctx)
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// coalesceCount fetches a count, coalesced with the concurrent calls if enabled with
+// WithCountCoalescing
+func (h *HeimdallClient) coalesceCount(ctx context.Context, key requestType, fetch func(ctx context.Context) (int64, error)) (int64, error) {
+	if h.counts == nil {
+		return fetch(ctx)
+	}
+
+	return h.counts.do(ctx, key, fetch)
+}
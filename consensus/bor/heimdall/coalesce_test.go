@@ -0,0 +1,75 @@
+package heimdall
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountCoalescing(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+
+		_, _ = w.Write([]byte(`{"height":"0","result":{"result":7}}`))
+	}))
+	defer srv.Close()
+
+	client := NewHeimdallClient(srv.URL, WithCountCoalescing(200*time.Millisecond))
+
+	var wg sync.WaitGroup
+
+	// staggered callers within the window share a single request
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			count, err := client.FetchCheckpointCount(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, int64(7), count)
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	wg.Wait()
+	require.Equal(t, int32(1), hits.Load())
+
+	// a call after the window opens a new batch
+	_, err := client.FetchCheckpointCount(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int32(2), hits.Load())
+
+	// the caller opening the batch timing out doesn't fail the others
+	short, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		_, err := client.FetchCheckpointCount(short)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+
+	count, err := client.FetchCheckpointCount(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(7), count)
+	require.Equal(t, int32(3), hits.Load())
+
+	wg.Wait()
+}
@@ -0,0 +1,48 @@
+package heimdall
+
+import (
+	"errors"
+	"sync"
+)
+
+// errNotModified is returned by internalFetch for a 304 reply to a conditional request
+var errNotModified = errors.New("not modified")
+
+// modifiedCache keeps the last body of the latest endpoints along with its
+// Last-Modified header, sent back as If-Modified-Since so that heimdall can reply
+// with a 304 if the body didn't change
+type modifiedCache struct {
+	mu      sync.Mutex
+	entries map[string]modifiedEntry
+}
+
+type modifiedEntry struct {
+	lastModified string
+	body         []byte
+}
+
+func newModifiedCache() *modifiedCache {
+	return &modifiedCache{entries: make(map[string]modifiedEntry)}
+}
+
+func (c *modifiedCache) get(key string) (modifiedEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+
+	return entry, ok
+}
+
+// put keeps the body if it has a Last-Modified header, otherwise forgets the last one
+func (c *modifiedCache) put(key string, lastModified string, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if lastModified == "" {
+		delete(c.entries, key)
+		return
+	}
+
+	c.entries[key] = modifiedEntry{lastModified: lastModified, body: body}
+}
@@ -0,0 +1,70 @@
+package heimdall
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIfModifiedSince(t *testing.T) {
+	t.Parallel()
+
+	const lastModified = "Wed, 14 Oct 2026 10:00:00 GMT"
+
+	var (
+		requests   atomic.Int32
+		conditions atomic.Int32
+	)
+
+	// The milestone is served once, then replied 304 to the conditional requests
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			conditions.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		w.Header().Set("Last-Modified", lastModified)
+		writeMilestone(w, 100)
+	}))
+	defer srv.Close()
+
+	client := NewHeimdallClient(srv.URL, WithRetryBudget(1), WithIfModifiedSince())
+	defer client.Close()
+
+	for i := 0; i < 3; i++ {
+		m, err := client.FetchMilestone(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, testMilestone(100).EndBlock, m.EndBlock)
+	}
+
+	require.Equal(t, int32(3), requests.Load())
+	require.Equal(t, int32(2), conditions.Load())
+
+	// the other endpoints aren't fetched conditionally
+	_, err := client.FetchMilestoneByNumber(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), conditions.Load())
+}
+
+func TestNotModifiedWithoutIfModifiedSince(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer srv.Close()
+
+	client := NewHeimdallClient(srv.URL, WithRetryBudget(1))
+	defer client.Close()
+
+	_, err := client.FetchMilestone(context.Background())
+	require.ErrorIs(t, err, ErrNotSuccessfulResponse)
+}
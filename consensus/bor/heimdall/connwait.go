@@ -0,0 +1,114 @@
+package heimdall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// ErrPoolExhausted is returned if a request waited longer than the limit set with
+// WithPoolWaitLimit for a connection, all of them being busy
+var ErrPoolExhausted = errors.New("heimdall connection pool exhausted")
+
+// connWaitTrace measures the wait of a request for a connection, either idle or
+// dialed, and cancels the request once it exceeds the limit
+type connWaitTrace struct {
+	h      *HeimdallClient
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	start     time.Time
+	timer     *time.Timer
+	got       bool
+	exhausted bool
+}
+
+// traceConnWait returns the request traced by a connWaitTrace, which must be stopped
+// once the response is read
+func (h *HeimdallClient) traceConnWait(req *http.Request) (*http.Request, *connWaitTrace) {
+	t := &connWaitTrace{h: h, cancel: func() {}}
+
+	ctx := req.Context()
+	if h.poolWaitLimit > 0 {
+		ctx, t.cancel = context.WithCancel(ctx)
+	}
+
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GetConn: t.getConn,
+		GotConn: t.gotConn,
+	})
+
+	return req.WithContext(ctx), t
+}
+
+func (t *connWaitTrace) getConn(string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// the transport may retry over another connection
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+
+	t.start = time.Now()
+	t.got = false
+
+	if limit := t.h.poolWaitLimit; limit > 0 {
+		t.timer = time.AfterFunc(limit, t.expire)
+	}
+}
+
+func (t *connWaitTrace) gotConn(httptrace.GotConnInfo) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.got = true
+
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+
+	t.h.metrics.connWait.Update(int64(time.Since(t.start)))
+}
+
+func (t *connWaitTrace) expire() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.got {
+		return
+	}
+
+	t.exhausted = true
+	t.cancel()
+
+	t.h.metrics.poolExhausted.Inc(1)
+}
+
+// err returns ErrPoolExhausted instead of the error of a request cancelled while
+// waiting for a connection
+func (t *connWaitTrace) err(err error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err != nil && t.exhausted {
+		return fmt.Errorf("%w: no connection within %v", ErrPoolExhausted, t.h.poolWaitLimit)
+	}
+
+	return err
+}
+
+func (t *connWaitTrace) stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+
+	t.cancel()
+}
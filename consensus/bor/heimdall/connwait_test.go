@@ -0,0 +1,69 @@
+package heimdall
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolWaitLimit(t *testing.T) {
+	t.Parallel()
+
+	const calls = 5
+
+	release := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		writeMilestone(w, 1)
+	}))
+	defer srv.Close()
+
+	// The single connection is held by the first request
+	client := NewHeimdallClient(srv.URL, WithMaxConnsPerHost(1), WithPoolWaitLimit(50*time.Millisecond))
+	defer client.Close()
+
+	var (
+		wg   sync.WaitGroup
+		errs = make(chan error, calls)
+	)
+
+	start := time.Now()
+
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := client.FetchMilestone(context.Background())
+			errs <- err
+		}()
+	}
+
+	// the requests of the pool exhausted fail fast while the first one is still held
+	var exhausted int
+
+	for exhausted < calls-1 {
+		select {
+		case err := <-errs:
+			require.ErrorIs(t, err, ErrPoolExhausted)
+
+			exhausted++
+		case <-time.After(5 * time.Second):
+			t.Fatal("requests blocked on the exhausted pool")
+		}
+	}
+
+	require.Less(t, time.Since(start), retryCall, "expect no retry")
+
+	close(release)
+	wg.Wait()
+
+	require.NoError(t, <-errs)
+}
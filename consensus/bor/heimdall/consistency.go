@@ -0,0 +1,58 @@
+package heimdall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/checkpoint"
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/milestone"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ErrConsistencyCheckFailed is returned if the canary heimdall disagrees with the
+// primary one, hinting at a split brain or a compromised node
+var ErrConsistencyCheckFailed = errors.New("heimdall consistency check failed")
+
+// canaryAttempts bounds the attempts to fetch from the canary heimdall
+const canaryAttempts = 3
+
+// canaryKey marks the context of the requests to the canary heimdall, which get
+// circuit breakers of their own
+type canaryKey struct{}
+
+// crossCheck fetches the same request from the canary heimdall, if configured, and
+// fails with ErrConsistencyCheckFailed unless it matches the primary response. The
+// canary is fetched with a few attempts at most.
+func crossCheck[T any](ctx context.Context, h *HeimdallClient, u *url.URL, primary *T, same func(a, b *T) bool) error {
+	if h.canaryURL == "" {
+		return nil
+	}
+
+	canaryURL, err := makeURL(h.canaryURL, u.Path, u.RawQuery)
+	if err != nil {
+		return err
+	}
+
+	canary, err := fetchWithRetryAttempts[T](context.WithValue(ctx, canaryKey{}, true), h, canaryURL, canaryAttempts)
+	if err != nil {
+		return fmt.Errorf("fetching from canary heimdall: %w", err)
+	}
+
+	if !same(primary, canary) {
+		log.Error("Primary and canary heimdall disagree", "path", u.Path)
+
+		return fmt.Errorf("%w: path %s", ErrConsistencyCheckFailed, u.Path)
+	}
+
+	return nil
+}
+
+func sameCheckpointResponse(a, b *checkpoint.CheckpointResponse) bool {
+	return a.Result.RootHash == b.Result.RootHash && sameBigInt(a.Result.EndBlock, b.Result.EndBlock)
+}
+
+func sameMilestoneResponse(a, b *milestone.MilestoneResponse) bool {
+	return a.Result.Hash == b.Result.Hash && sameBigInt(a.Result.EndBlock, b.Result.EndBlock)
+}
@@ -0,0 +1,108 @@
+package heimdall
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/checkpoint"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newCheckpointServer starts a server serving the given checkpoint and milestone
+func newCheckpointServer(t *testing.T, rootHash common.Hash, endBlock int64) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/checkpoints/latest", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(checkpoint.CheckpointResponse{
+			Height: "0",
+			Result: checkpoint.Checkpoint{
+				StartBlock: big.NewInt(0),
+				EndBlock:   big.NewInt(endBlock),
+				RootHash:   rootHash,
+			},
+		})
+	})
+	mux.HandleFunc("/milestone/latest", func(w http.ResponseWriter, _ *http.Request) {
+		writeMilestone(w, endBlock/16)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestConsistencyCheck(t *testing.T) {
+	t.Parallel()
+
+	primary := newCheckpointServer(t, common.HexToHash("0x1"), 512)
+	agreeing := newCheckpointServer(t, common.HexToHash("0x1"), 512)
+	forked := newCheckpointServer(t, common.HexToHash("0x2"), 512)
+	lagging := newCheckpointServer(t, common.HexToHash("0x1"), 256)
+
+	tests := []struct {
+		name       string
+		canary     string
+		checkpoint error
+		milestone  error
+	}{
+		{name: "agreeing canary", canary: agreeing.URL},
+		{name: "different root hash", canary: forked.URL, checkpoint: ErrConsistencyCheckFailed},
+		{name: "different end block", canary: lagging.URL, checkpoint: ErrConsistencyCheckFailed, milestone: ErrConsistencyCheckFailed},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := NewHeimdallClient(primary.URL, WithConsistencyCheck(test.canary))
+
+			_, err := client.FetchCheckpoint(context.Background(), -1)
+			require.ErrorIs(t, err, test.checkpoint)
+
+			_, err = client.FetchMilestone(context.Background())
+			require.ErrorIs(t, err, test.milestone)
+		})
+	}
+}
+
+func TestConsistencyCheckCanaryDown(t *testing.T) {
+	t.Parallel()
+
+	primary := newCheckpointServer(t, common.HexToHash("0x1"), 512)
+
+	var requests atomic.Int32
+
+	canary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer canary.Close()
+
+	client := NewHeimdallClient(primary.URL, WithConsistencyCheck(canary.URL), WithCircuitBreaker(canaryAttempts, time.Minute), WithSharedCircuitBreaker())
+	defer client.Close()
+
+	client.retryInterval = time.Millisecond
+
+	// the canary fails after a few attempts, without retrying forever
+	_, err := client.FetchCheckpoint(context.Background(), -1)
+	require.ErrorIs(t, err, ErrNotSuccessfulResponse)
+	require.Equal(t, int32(canaryAttempts), requests.Load())
+
+	// the canary opened its own breaker, not the one of the primary
+	_, err = client.FetchCheckpoint(context.Background(), -1)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	require.ErrorContains(t, err, "canary")
+	require.True(t, client.breakers.get(context.Background(), "").allow(), "expect the primary breaker to stay closed")
+}
@@ -0,0 +1,74 @@
+package heimdall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Counts holds the checkpoint and milestone counts of heimdall
+type Counts struct {
+	Checkpoints int64
+	Milestones  int64
+}
+
+// CountsError holds the errors of the counts which couldn't be fetched by
+// FetchCounts, nil for the fetched ones
+type CountsError struct {
+	Checkpoint error
+	Milestone  error
+}
+
+func (e *CountsError) Error() string {
+	failures := make([]string, 0, 2)
+
+	if e.Checkpoint != nil {
+		failures = append(failures, fmt.Sprintf("checkpoint count: %v", e.Checkpoint))
+	}
+
+	if e.Milestone != nil {
+		failures = append(failures, fmt.Sprintf("milestone count: %v", e.Milestone))
+	}
+
+	return "fetching counts failed: " + strings.Join(failures, "; ")
+}
+
+// Is reports whether either of the count errors matches the target
+func (e *CountsError) Is(target error) bool {
+	return errors.Is(e.Checkpoint, target) || errors.Is(e.Milestone, target)
+}
+
+// FetchCounts fetches the checkpoint and milestone counts concurrently. The fetched
+// count is returned even if the other one fails, along with a CountsError holding
+// the failure.
+func (h *HeimdallClient) FetchCounts(ctx context.Context) (Counts, error) {
+	var (
+		wg     sync.WaitGroup
+		counts Counts
+		errs   CountsError
+	)
+
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		counts.Checkpoints, errs.Checkpoint = h.FetchCheckpointCount(ctx)
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		counts.Milestones, errs.Milestone = h.FetchMilestoneCount(ctx)
+	}()
+
+	wg.Wait()
+
+	if errs.Checkpoint != nil || errs.Milestone != nil {
+		return counts, &errs
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,65 @@
+package heimdall
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchCounts(t *testing.T) {
+	t.Parallel()
+
+	newServer := func(t *testing.T, failMilestones bool) *httptest.Server {
+		t.Helper()
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// both counts are requested before either is served
+			time.Sleep(100 * time.Millisecond)
+
+			switch {
+			case r.URL.Path == fetchCheckpointCount:
+				_, _ = w.Write([]byte(`{"height":"0","result":{"result":5}}`))
+			case r.URL.Path == fetchMilestoneCount && !failMilestones:
+				_, _ = w.Write([]byte(`{"height":"0","result":{"count":7}}`))
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}))
+		t.Cleanup(srv.Close)
+
+		return srv
+	}
+
+	t.Run("both counts", func(t *testing.T) {
+		t.Parallel()
+
+		client := NewHeimdallClient(newServer(t, false).URL)
+
+		start := time.Now()
+
+		counts, err := client.FetchCounts(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, Counts{Checkpoints: 5, Milestones: 7}, counts)
+		require.Less(t, time.Since(start), 190*time.Millisecond, "expect the counts fetched concurrently")
+	})
+
+	t.Run("milestone count failure", func(t *testing.T) {
+		t.Parallel()
+
+		client := NewHeimdallClient(newServer(t, true).URL, WithRetryBudget(1))
+
+		counts, err := client.FetchCounts(context.Background())
+		require.ErrorIs(t, err, ErrNotSuccessfulResponse)
+		require.Equal(t, int64(5), counts.Checkpoints)
+
+		var countsErr *CountsError
+		require.True(t, errors.As(err, &countsErr))
+		require.NoError(t, countsErr.Checkpoint)
+		require.ErrorIs(t, countsErr.Milestone, ErrNotSuccessfulResponse)
+	})
+}
@@ -0,0 +1,50 @@
+package heimdall
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// decodeError is returned by Fetch if the response body couldn't be decoded
+type decodeError struct {
+	err error
+
+	// truncated bodies ended before the end of their JSON value, e.g. cut off by a
+	// flaky connection, rather than being malformed
+	truncated bool
+}
+
+func newDecodeError(err error, body []byte) *decodeError {
+	var syntaxErr *json.SyntaxError
+
+	return &decodeError{
+		err:       err,
+		truncated: errors.As(err, &syntaxErr) && syntaxErr.Offset >= int64(len(body)),
+	}
+}
+
+func (e *decodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *decodeError) Unwrap() error {
+	return e.err
+}
+
+// decodeRetriesExhausted reports whether the decode failure isn't retried anymore if
+// configured with WithDecodeRetries, counting the failures retried so far. Other
+// errors are retried as usual.
+func (h *HeimdallClient) decodeRetriesExhausted(err error, failures *int) bool {
+	var decodeErr *decodeError
+	if h.decodeRetries <= 0 || !errors.As(err, &decodeErr) {
+		return false
+	}
+
+	if !decodeErr.truncated || *failures >= h.decodeRetries {
+		return true
+	}
+
+	*failures++
+
+	return false
+}
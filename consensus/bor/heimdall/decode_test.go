@@ -0,0 +1,105 @@
+package heimdall
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeRetries(t *testing.T) {
+	t.Parallel()
+
+	const truncated = `{"height":"0","result":{"start_block":1,`
+
+	tests := []struct {
+		name     string
+		bodies   []string // served in turn, the last one repeatedly
+		requests int32
+		err      bool
+	}{
+		{name: "transient truncation", bodies: []string{truncated, truncated, ""}, requests: 3},
+		{name: "persistent truncation", bodies: []string{truncated}, requests: 3, err: true},
+		{name: "malformed syntax", bodies: []string{`{"height":"0",,"result":{}}`}, requests: 1, err: true},
+		{name: "malformed field", bodies: []string{`{"height":"0","result":{"end_block":"five"}}`}, requests: 1, err: true},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var requests atomic.Int32
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				n := int(requests.Add(1))
+				if n > len(test.bodies) {
+					n = len(test.bodies)
+				}
+
+				if body := test.bodies[n-1]; body != "" {
+					_, _ = w.Write([]byte(body))
+					return
+				}
+
+				writeMilestone(w, 1)
+			}))
+			defer srv.Close()
+
+			client := NewHeimdallClient(srv.URL, WithDecodeRetries(2), WithRetryBudget(10))
+			client.retryInterval = time.Millisecond
+
+			_, err := client.FetchMilestone(context.Background())
+			if test.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, test.requests, requests.Load())
+		})
+	}
+
+	// a truncated body isn't served from the cache to the retries
+	t.Run("cached truncation", func(t *testing.T) {
+		t.Parallel()
+
+		var requests atomic.Int32
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			if requests.Add(1) == 1 {
+				_, _ = w.Write([]byte(truncated))
+				return
+			}
+
+			writeMilestone(w, 1)
+		}))
+		defer srv.Close()
+
+		client := NewHeimdallClient(srv.URL, WithResponseCache(time.Minute), WithDecodeRetries(2), WithRetryBudget(5))
+		client.retryInterval = time.Millisecond
+
+		m, err := client.FetchMilestone(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, testMilestone(1).EndBlock, m.EndBlock)
+		require.Equal(t, int32(2), requests.Load())
+
+		// the decoded body is cached
+		_, err = client.FetchMilestone(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int32(2), requests.Load())
+	})
+
+	// the decoding error is still returned as such
+	var syntaxErr *json.SyntaxError
+
+	err := newDecodeError(json.Unmarshal([]byte(truncated), new(interface{})), []byte(truncated))
+	require.ErrorAs(t, err, &syntaxErr)
+	require.True(t, err.truncated)
+}
@@ -0,0 +1,40 @@
+package heimdall
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// dryRunBody is the body of the dry run responses without a canned one, decoded as
+// the zero value of any response
+const dryRunBody = "{}"
+
+// dryRunTransport logs the requests instead of sending them, and replies with the
+// canned response of their path, see WithDryRun
+type dryRunTransport struct {
+	responses map[string]string
+}
+
+func (t dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	log.Info("Heimdall dry run request", "method", req.Method, "url", req.URL.Redacted())
+
+	body, ok := t.responses[req.URL.Path]
+	if !ok {
+		body = dryRunBody
+	}
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          io.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,90 @@
+package heimdall
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun(t *testing.T) {
+	var requests atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+	}))
+	defer srv.Close()
+
+	var urls []string
+
+	handler := log.Root().GetHandler()
+	log.Root().SetHandler(log.FuncHandler(func(r *log.Record) error {
+		if r.Msg == "Heimdall dry run request" {
+			urls = append(urls, fmt.Sprint(r.Ctx[3]))
+		}
+
+		return nil
+	}, log.LvlTrace))
+
+	defer log.Root().SetHandler(handler)
+
+	client := NewHeimdallClient(srv.URL, WithRetryBudget(1), WithDryRun(map[string]string{
+		fetchCheckpointCount: `{"height":"0","result":{"result":42}}`,
+	}))
+	defer client.Close()
+
+	ctx := context.Background()
+
+	tests := []struct {
+		fetch func() error
+		url   string
+	}{
+		{
+			fetch: func() error {
+				count, err := client.FetchCheckpointCount(ctx)
+				require.Equal(t, int64(42), count, "expect the canned response")
+
+				return err
+			},
+			url: srv.URL + fetchCheckpointCount,
+		},
+		{
+			fetch: func() error { _, err := client.FetchCheckpoint(ctx, -1); return err },
+			url:   srv.URL + "/checkpoints/latest",
+		},
+		{
+			fetch: func() error { _, err := client.FetchMilestone(ctx); return err },
+			url:   srv.URL + fetchMilestone,
+		},
+		{
+			fetch: func() error { _, err := client.FetchMilestoneCount(ctx); return err },
+			url:   srv.URL + fetchMilestoneCount,
+		},
+		{
+			fetch: func() error { _, err := client.Span(ctx, 7); return err },
+			url:   srv.URL + "/bor/span/7",
+		},
+		{
+			fetch: func() error { _, err := client.FetchLatestSpan(ctx); return err },
+			url:   srv.URL + "/" + fetchLatestSpan,
+		},
+		{
+			fetch: func() error { _, err := client.StateSyncEvents(ctx, 10, 100); return err },
+			url:   srv.URL + "/clerk/event-record/list?from-id=10&to-time=100&limit=50",
+		},
+	}
+
+	for _, test := range tests {
+		urls = nil
+
+		require.NoError(t, test.fetch(), test.url)
+		require.Equal(t, []string{test.url}, urls)
+	}
+
+	require.Zero(t, requests.Load(), "expect no request sent")
+}
@@ -0,0 +1,125 @@
+package heimdall
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type fetchMetaKey struct{}
+
+// FetchMeta collects the attempts made by the heimdall requests sent with a
+// context, whether stale results were served, and the last response header, so
+// that callers can tell a degraded success from a first-try one or self-throttle
+// on rate limit headers. It is safe for concurrent requests.
+type FetchMeta struct {
+	requests atomic.Int32
+	attempts atomic.Int32
+	stale    atomic.Bool
+
+	mu       sync.Mutex
+	header   http.Header
+	served   string
+	servedAt time.Time
+
+	// parent is the meta of the caller, which records the requests too
+	parent *FetchMeta
+}
+
+// WithFetchMeta returns a context recording into meta the attempts of the requests
+// sent with it
+func WithFetchMeta(ctx context.Context, meta *FetchMeta) context.Context {
+	return context.WithValue(ctx, fetchMetaKey{}, meta)
+}
+
+// Requests returns the number of requests sent, each of them made of one or more
+// attempts
+func (m *FetchMeta) Requests() int {
+	return int(m.requests.Load())
+}
+
+// Attempts returns the number of attempts made by all the requests
+func (m *FetchMeta) Attempts() int {
+	return int(m.attempts.Load())
+}
+
+// Retried reports whether any request needed more than one attempt
+func (m *FetchMeta) Retried() bool {
+	return m.Attempts() > m.Requests()
+}
+
+// Stale reports whether a result was served from the last known one after a failed
+// fetch, see WithStaleCountFallback
+func (m *FetchMeta) Stale() bool {
+	return m.stale.Load()
+}
+
+// Header returns the header of the last response received, nil if none. Responses
+// served from the cache have none.
+func (m *FetchMeta) Header() http.Header {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.header
+}
+
+// fetchMetaFrom returns the meta of the context, nil if none
+func fetchMetaFrom(ctx context.Context) *FetchMeta {
+	meta, _ := ctx.Value(fetchMetaKey{}).(*FetchMeta)
+	return meta
+}
+
+// RequestID returns the X-Request-ID header echoed by the last response, empty if
+// none. It is the id of the request, unless replaced by a proxy.
+func (m *FetchMeta) RequestID() string {
+	return m.Header().Get(requestIDHeader)
+}
+
+// recordAttempts adds a request made of the given attempts to the meta of the
+// context, if any
+func recordAttempts(ctx context.Context, attempts int) {
+	if attempts == 0 {
+		return
+	}
+
+	for meta := fetchMetaFrom(ctx); meta != nil; meta = meta.parent {
+		meta.requests.Add(1)
+		meta.attempts.Add(int32(attempts))
+	}
+}
+
+// markStale flags the meta of the context, if any, as served a stale result
+func markStale(ctx context.Context) {
+	for meta := fetchMetaFrom(ctx); meta != nil; meta = meta.parent {
+		meta.stale.Store(true)
+	}
+}
+
+// recordHeader stores the response header in the meta of the context, if any
+func recordHeader(ctx context.Context, header http.Header) {
+	if header == nil {
+		return
+	}
+
+	for meta := fetchMetaFrom(ctx); meta != nil; meta = meta.parent {
+		meta.mu.Lock()
+		meta.header = header
+		meta.mu.Unlock()
+	}
+}
+
+// recordServed stores the url of the first successful response in the meta of the
+// context, if any
+func recordServed(ctx context.Context, u *url.URL) {
+	for meta := fetchMetaFrom(ctx); meta != nil; meta = meta.parent {
+		meta.mu.Lock()
+		if meta.served == "" {
+			meta.served = u.String()
+			meta.servedAt = time.Now()
+		}
+		meta.mu.Unlock()
+	}
+}
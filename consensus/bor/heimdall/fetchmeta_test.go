@@ -0,0 +1,129 @@
+package heimdall
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/checkpoint"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchMeta(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+
+	// Fail the first two attempts
+	handler := &HttpHandlerFake{}
+	handler.handleFetchCheckpoint = func(w http.ResponseWriter, _ *http.Request) {
+		if requests.Add(1) < 3 {
+			w.WriteHeader(500) // Return 500 Internal Server Error.
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(checkpoint.CheckpointResponse{
+			Height: "0",
+			Result: checkpoint.Checkpoint{EndBlock: big.NewInt(512)},
+		})
+	}
+
+	u, err := checkpointURL(startMockHeimdallServer(t, handler), -1)
+	require.NoError(t, err)
+
+	client := NewHeimdallClient(u.String())
+	client.retryInterval = 10 * time.Millisecond
+
+	var degraded FetchMeta
+
+	_, err = fetchWithRetry[checkpoint.CheckpointResponse](WithFetchMeta(context.Background(), &degraded), client, u)
+	require.NoError(t, err)
+	require.Equal(t, 1, degraded.Requests())
+	require.Equal(t, 3, degraded.Attempts())
+	require.True(t, degraded.Retried())
+
+	var healthy FetchMeta
+
+	_, err = fetchWithRetry[checkpoint.CheckpointResponse](WithFetchMeta(context.Background(), &healthy), client, u)
+	require.NoError(t, err)
+	require.Equal(t, 1, healthy.Requests())
+	require.Equal(t, 1, healthy.Attempts())
+	require.False(t, healthy.Retried())
+}
+
+func TestFetchMetaHeader(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		writeMilestone(w, 1)
+	}))
+	defer srv.Close()
+
+	client := NewHeimdallClient(srv.URL)
+
+	var meta FetchMeta
+
+	require.Nil(t, meta.Header())
+
+	_, err := client.FetchMilestone(WithFetchMeta(context.Background(), &meta))
+	require.NoError(t, err)
+	require.Equal(t, "42", meta.Header().Get("X-RateLimit-Remaining"))
+}
+
+func TestFetchMetaRequestID(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu   sync.Mutex
+		sent []string
+	)
+
+	// The first attempt fails, the ids are echoed back
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		w.Header().Set(requestIDHeader, id)
+
+		mu.Lock()
+		sent = append(sent, id)
+		attempt := len(sent)
+		mu.Unlock()
+
+		if attempt == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		writeMilestone(w, 1)
+	}))
+	defer srv.Close()
+
+	client := NewHeimdallClient(srv.URL, WithRequestJournal(4), WithFastRetry(time.Millisecond, 1))
+
+	var meta FetchMeta
+
+	require.Empty(t, meta.RequestID())
+
+	_, err := client.FetchMilestone(WithFetchMeta(context.Background(), &meta))
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Len(t, sent, 2)
+	require.NotEmpty(t, sent[0])
+	require.NotEqual(t, sent[0], sent[1], "expect an id per attempt")
+	require.Equal(t, sent[1], meta.RequestID())
+
+	records := client.RecentRequests()
+	require.Len(t, records, 2)
+	require.Equal(t, sent[0], records[0].ID)
+	require.Equal(t, sent[1], records[1].ID)
+}
@@ -0,0 +1,94 @@
+package heimdall
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/checkpoint"
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/milestone"
+)
+
+// EndpointStatus is the outcome of probing a heimdall endpoint
+type EndpointStatus struct {
+	// Err is nil if the endpoint served a valid response
+	Err error
+
+	// StatusCode of the response, zero if none was received
+	StatusCode int
+
+	// Latency of the probe
+	Latency time.Duration
+}
+
+// endpointProbe fetches an endpoint once with the given request
+type endpointProbe struct {
+	url   func(urlString string) (*url.URL, error)
+	fetch func(ctx context.Context, request *Request) error
+}
+
+// probe returns a probe decoding the response of the endpoint into T
+func probe[T any](urlFn func(urlString string) (*url.URL, error)) endpointProbe {
+	return endpointProbe{
+		url: urlFn,
+		fetch: func(ctx context.Context, request *Request) error {
+			_, err := Fetch[T](ctx, request)
+			return err
+		},
+	}
+}
+
+// healthProbes are the endpoints probed by EndpointHealth, by path
+var healthProbes = map[string]endpointProbe{
+	"/checkpoints/latest": probe[checkpoint.CheckpointResponse](func(urlString string) (*url.URL, error) {
+		return checkpointURL(urlString, -1)
+	}),
+	fetchCheckpointCount:  probe[checkpoint.CheckpointCountResponse](checkpointCountURL),
+	fetchMilestone:        probe[milestone.MilestoneResponse](milestoneURL),
+	fetchMilestoneCount:   probe[milestone.MilestoneCountResponse](milestoneCountURL),
+	"/" + fetchLatestSpan: probe[SpanResponse](latestSpanURL),
+}
+
+// EndpointHealth probes the latest checkpoint, milestone and span endpoints and the
+// count ones concurrently, with a single attempt each, and returns their status by
+// path. Responses cached with WithResponseCache are reported without a request.
+func (h *HeimdallClient) EndpointHealth(ctx context.Context) map[string]EndpointStatus {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		health = make(map[string]EndpointStatus, len(healthProbes))
+	)
+
+	for path, p := range healthProbes {
+		path, p := path, p
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			var status EndpointStatus
+
+			u, err := p.url(h.urlString)
+			if err != nil {
+				status.Err = err
+			} else {
+				request := h.newRequest(ctx, u, 1)
+
+				start := time.Now()
+				status.Err = p.fetch(ctx, request)
+				status.Latency = time.Since(start)
+				status.StatusCode = request.status
+			}
+
+			mu.Lock()
+			health[path] = status
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	return health
+}
@@ -0,0 +1,46 @@
+package heimdall
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointHealth(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case fetchMilestone:
+			writeMilestone(w, 1)
+		case fetchCheckpointCount:
+			_, _ = w.Write([]byte(`{"height":"0","result":{"result":5}}`))
+		case fetchMilestoneCount:
+			_, _ = w.Write([]byte(`{"height":"0","result":{"count":"not a number"}}`))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	health := NewHeimdallClient(srv.URL).EndpointHealth(context.Background())
+	require.Len(t, health, 5)
+
+	for _, path := range []string{fetchMilestone, fetchCheckpointCount} {
+		require.NoError(t, health[path].Err, path)
+		require.Equal(t, http.StatusOK, health[path].StatusCode, path)
+		require.Positive(t, health[path].Latency, path)
+	}
+
+	// served, but not decodable
+	require.Error(t, health[fetchMilestoneCount].Err)
+	require.Equal(t, http.StatusOK, health[fetchMilestoneCount].StatusCode)
+
+	for _, path := range []string{"/checkpoints/latest", "/" + fetchLatestSpan} {
+		require.ErrorIs(t, health[path].Err, ErrNotSuccessfulResponse, path)
+		require.Equal(t, http.StatusServiceUnavailable, health[path].StatusCode, path)
+	}
+}
@@ -0,0 +1,160 @@
+// Package heimdalltest provides utilities to record the responses of a heimdall
+// server and replay them in tests, and to check the span configuration of a client
+// against a heimdall.
+package heimdalltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Exchange is a recorded request and its raw response
+type Exchange struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"` // path and query, the host isn't recorded
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+// Recorder is an http.RoundTripper writing every request it sends and the response
+// to a file of its directory, in order. Use it with heimdall.WithTransport.
+type Recorder struct {
+	dir  string
+	next http.RoundTripper
+
+	mu    sync.Mutex
+	count int
+}
+
+// NewRecorder returns a recorder writing to the given directory, which is created if
+// needed, and sending the requests with next, or the default transport if nil
+func NewRecorder(dir string, next http.RoundTripper) (*Recorder, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &Recorder{dir: dir, next: next}, nil
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	exchange := Exchange{
+		Method:     req.Method,
+		URL:        req.URL.RequestURI(),
+		StatusCode: res.StatusCode,
+		Header:     res.Header,
+		Body:       body,
+	}
+
+	if err := r.write(&exchange); err != nil {
+		return nil, fmt.Errorf("recording %s: %w", exchange.URL, err)
+	}
+
+	return res, nil
+}
+
+func (r *Recorder) write(exchange *Exchange) error {
+	data, err := json.MarshalIndent(exchange, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.count++
+
+	return os.WriteFile(filepath.Join(r.dir, fmt.Sprintf("%06d.json", r.count)), data, 0o644)
+}
+
+// Replayer is an http.Handler serving the exchanges of a recorder directory. The
+// responses to a request are served in the recorded order, the last one repeating.
+type Replayer struct {
+	mu        sync.Mutex
+	exchanges map[string][]*Exchange
+}
+
+// NewReplayer loads the exchanges recorded in the given directory
+func NewReplayer(dir string) (*Replayer, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+
+	replayer := &Replayer{exchanges: make(map[string][]*Exchange)}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		exchange := new(Exchange)
+		if err := json.Unmarshal(data, exchange); err != nil {
+			return nil, fmt.Errorf("decoding %s: %w", file, err)
+		}
+
+		key := exchange.Method + " " + exchange.URL
+		replayer.exchanges[key] = append(replayer.exchanges[key], exchange)
+	}
+
+	return replayer, nil
+}
+
+// ServeHTTP implements http.Handler, replying 404 to requests never recorded
+func (r *Replayer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	key := req.Method + " " + req.URL.RequestURI()
+
+	r.mu.Lock()
+	recorded := r.exchanges[key]
+
+	if len(recorded) == 0 {
+		r.mu.Unlock()
+		http.NotFound(w, req)
+
+		return
+	}
+
+	exchange := recorded[0]
+	if len(recorded) > 1 {
+		r.exchanges[key] = recorded[1:]
+	}
+	r.mu.Unlock()
+
+	for name, values := range exchange.Header {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+
+	w.WriteHeader(exchange.StatusCode)
+	_, _ = w.Write(exchange.Body)
+}
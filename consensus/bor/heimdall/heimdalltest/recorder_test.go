@@ -0,0 +1,81 @@
+package heimdalltest
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall"
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/checkpoint"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	t.Parallel()
+
+	var count int64
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/checkpoints/count":
+			count++
+			_ = json.NewEncoder(w).Encode(checkpoint.CheckpointCountResponse{Height: "0", Result: checkpoint.CheckpointCount{Result: count}})
+		case "/checkpoints/latest":
+			_ = json.NewEncoder(w).Encode(checkpoint.CheckpointResponse{
+				Height: "0",
+				Result: checkpoint.Checkpoint{
+					StartBlock: big.NewInt(0),
+					EndBlock:   big.NewInt(255),
+					RootHash:   common.HexToHash("0x1"),
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+
+	recorder, err := NewRecorder(dir, nil)
+	require.NoError(t, err)
+
+	fetch := func(client *heimdall.HeimdallClient) (int64, int64, *checkpoint.Checkpoint) {
+		first, err := client.FetchCheckpointCount(context.Background())
+		require.NoError(t, err)
+
+		second, err := client.FetchCheckpointCount(context.Background())
+		require.NoError(t, err)
+
+		cp, err := client.FetchCheckpoint(context.Background(), -1)
+		require.NoError(t, err)
+
+		return first, second, cp
+	}
+
+	first, second, cp := fetch(heimdall.NewHeimdallClient(srv.URL, heimdall.WithTransport(recorder)))
+	require.Equal(t, int64(1), first)
+	require.Equal(t, int64(2), second)
+
+	// the replay reproduces the responses, in order
+	replayer, err := NewReplayer(dir)
+	require.NoError(t, err)
+
+	replay := httptest.NewServer(replayer)
+	defer replay.Close()
+
+	replayedFirst, replayedSecond, replayedCp := fetch(heimdall.NewHeimdallClient(replay.URL))
+	require.Equal(t, first, replayedFirst)
+	require.Equal(t, second, replayedSecond)
+	require.Equal(t, cp, replayedCp)
+
+	// the last response repeats
+	replayedCount, err := heimdall.NewHeimdallClient(replay.URL).FetchCheckpointCount(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, second, replayedCount)
+}
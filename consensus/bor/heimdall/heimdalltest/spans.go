@@ -0,0 +1,93 @@
+package heimdalltest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/span"
+)
+
+// ErrSpanMismatch is returned by VerifySpans if the span computed for a block
+// doesn't match the one served by heimdall
+var ErrSpanMismatch = errors.New("span computed for block doesn't match heimdall")
+
+// SpanSource fetches spans both by id and by block, like the heimdall client
+// configured with a span length
+type SpanSource interface {
+	Span(ctx context.Context, spanID uint64) (*span.HeimdallSpan, error)
+	SpanByBlock(ctx context.Context, blockNumber uint64) (*span.HeimdallSpan, error)
+}
+
+// VerifySpans checks that for every block in [from, to], the span returned by
+// SpanByBlock covers the block and is the one fetched with Span by its id. It
+// fails with ErrSpanMismatch if the span length of the source disagrees with
+// heimdall.
+func VerifySpans(ctx context.Context, source SpanSource, from, to uint64) error {
+	fetched := make(map[uint64]span.Span)
+
+	for number := from; number <= to; number++ {
+		byBlock, err := source.SpanByBlock(ctx, number)
+		if err != nil {
+			return fmt.Errorf("span of block %d: %w", number, err)
+		}
+
+		if number < byBlock.StartBlock || number > byBlock.EndBlock {
+			return fmt.Errorf("%w: block %d, span %d [%d, %d]", ErrSpanMismatch, number, byBlock.ID, byBlock.StartBlock, byBlock.EndBlock)
+		}
+
+		byID, ok := fetched[byBlock.ID]
+		if !ok {
+			heimdallSpan, err := source.Span(ctx, byBlock.ID)
+			if err != nil {
+				return fmt.Errorf("span %d: %w", byBlock.ID, err)
+			}
+
+			byID = heimdallSpan.Span
+			fetched[byBlock.ID] = byID
+		}
+
+		if byID != byBlock.Span {
+			return fmt.Errorf("%w: block %d, span %+v by block, %+v by id", ErrSpanMismatch, number, byBlock.Span, byID)
+		}
+
+		if number == to {
+			break // avoid overflowing at the largest block
+		}
+	}
+
+	return nil
+}
+
+// SpanHandler is an http.Handler serving the spans of a heimdall using the given
+// span length, after the zeroth span. Spans are served at /bor/span/{id}, and
+// 404 is returned for any other path.
+type SpanHandler struct {
+	SpanLength uint64
+}
+
+func (h SpanHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	spanID, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/bor/span/"), 10, 64)
+	if err != nil || !strings.HasPrefix(r.URL.Path, "/bor/span/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	result := span.Span{ID: spanID, EndBlock: span.ZerothSpanEnd}
+	if spanID > 0 {
+		result.StartBlock = span.ZerothSpanEnd + (spanID-1)*h.SpanLength + 1
+		result.EndBlock = result.StartBlock + h.SpanLength - 1
+	}
+
+	_ = json.NewEncoder(w).Encode(struct {
+		Height string            `json:"height"`
+		Result span.HeimdallSpan `json:"result"`
+	}{
+		Height: "0",
+		Result: span.HeimdallSpan{Span: result},
+	})
+}
@@ -0,0 +1,30 @@
+package heimdalltest
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall"
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/span"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifySpans(t *testing.T) {
+	t.Parallel()
+
+	const spanLength = 64
+
+	srv := httptest.NewServer(SpanHandler{SpanLength: spanLength})
+	defer srv.Close()
+
+	from, to := uint64(span.ZerothSpanEnd-2), uint64(span.ZerothSpanEnd+3*spanLength)
+
+	client := heimdall.NewHeimdallClient(srv.URL, heimdall.WithSpanLength(spanLength))
+	require.NoError(t, VerifySpans(context.Background(), client, from, to))
+
+	// a client configured with another span length gets the wrong span
+	client = heimdall.NewHeimdallClient(srv.URL, heimdall.WithSpanLength(spanLength/2))
+	require.ErrorIs(t, VerifySpans(context.Background(), client, from, to), ErrSpanMismatch)
+}
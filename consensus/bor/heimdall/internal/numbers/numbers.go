@@ -0,0 +1,52 @@
+// Package numbers decodes the numbers served by Heimdall, either as JSON numbers or
+// as strings depending on its version
+package numbers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// ParseBigInt decodes a block number serialized either as a number or as a string,
+// nil if missing or null
+func ParseBigInt(data []byte) (*big.Int, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		s = string(data)
+	}
+
+	number, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid number %s", data)
+	}
+
+	return number, nil
+}
+
+// ParseCount decodes a count serialized either as a number or as a string. A missing,
+// null or empty count decodes to 0, like a plain int64 field.
+func ParseCount(data []byte) (int64, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return 0, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		if s == "" {
+			return 0, nil
+		}
+
+		return strconv.ParseInt(s, 10, 64)
+	}
+
+	var count int64
+	err := json.Unmarshal(data, &count)
+
+	return count, err
+}
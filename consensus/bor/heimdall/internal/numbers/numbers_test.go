@@ -0,0 +1,79 @@
+package numbers
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBigInt(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		data   string
+		number *big.Int
+		err    bool
+	}{
+		{name: "number", data: `512`, number: big.NewInt(512)},
+		{name: "string", data: `"512"`, number: big.NewInt(512)},
+		{name: "missing", data: ``},
+		{name: "null", data: `null`},
+		{name: "empty string", data: `""`, err: true},
+		{name: "invalid string", data: `"five"`, err: true},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			number, err := ParseBigInt([]byte(test.data))
+			if test.err {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.number, number)
+		})
+	}
+}
+
+func TestParseCount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		data  string
+		count int64
+		err   bool
+	}{
+		{name: "number", data: `5`, count: 5},
+		{name: "string", data: `"5"`, count: 5},
+		{name: "missing", data: ``},
+		{name: "null", data: `null`},
+		{name: "empty string", data: `""`},
+		{name: "invalid string", data: `"five"`, err: true},
+		{name: "object", data: `{}`, err: true},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			count, err := ParseCount([]byte(test.data))
+			if test.err {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.count, count)
+		})
+	}
+}
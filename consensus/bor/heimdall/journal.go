@@ -0,0 +1,63 @@
+package heimdall
+
+import (
+	"sync"
+	"time"
+)
+
+// RequestRecord describes a request sent to heimdall
+type RequestRecord struct {
+	ID         string // sent in the X-Request-ID header
+	Path       string
+	StatusCode int // zero if no response was received
+	Duration   time.Duration
+	Err        error
+	Time       time.Time
+}
+
+// requestJournal is a ring buffer of the most recent requests
+type requestJournal struct {
+	mu      sync.Mutex
+	records []RequestRecord
+	next    int
+	full    bool
+}
+
+func newRequestJournal(size int) *requestJournal {
+	return &requestJournal{records: make([]RequestRecord, size)}
+}
+
+func (j *requestJournal) add(record RequestRecord) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.records[j.next] = record
+	j.next++
+
+	if j.next == len(j.records) {
+		j.next = 0
+		j.full = true
+	}
+}
+
+// recent returns the records, the oldest first
+func (j *requestJournal) recent() []RequestRecord {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !j.full {
+		return append([]RequestRecord(nil), j.records[:j.next]...)
+	}
+
+	return append(append([]RequestRecord(nil), j.records[j.next:]...), j.records[:j.next]...)
+}
+
+// RecentRequests returns the last requests sent to heimdall, the oldest first, if
+// enabled with WithRequestJournal. Responses served from the cache aren't recorded.
+func (h *HeimdallClient) RecentRequests() []RequestRecord {
+	if h.journal == nil {
+		return nil
+	}
+
+	return h.journal.recent()
+}
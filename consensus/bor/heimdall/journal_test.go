@@ -0,0 +1,47 @@
+package heimdall
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/span"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestJournal(t *testing.T) {
+	t.Parallel()
+
+	srv := newSpanServer(t, span.DefaultSpanLength)
+
+	client := NewHeimdallClient(srv.URL, WithRequestJournal(3), WithRetryBudget(1))
+
+	for id := uint64(1); id <= 3; id++ {
+		_, err := client.Span(context.Background(), id)
+		require.NoError(t, err)
+	}
+
+	// the span list isn't served, so the only attempt fails
+	_, err := client.FetchSpanList(context.Background(), 1, 10)
+	require.Error(t, err)
+
+	records := client.RecentRequests()
+	require.Len(t, records, 3)
+
+	for i, id := range []uint64{2, 3} {
+		require.Equal(t, fmt.Sprintf(fetchSpanFormat, id), records[i].Path)
+		require.Equal(t, http.StatusOK, records[i].StatusCode)
+		require.NoError(t, records[i].Err)
+		require.False(t, records[i].Time.IsZero())
+	}
+
+	require.Equal(t, fetchSpanListPath, records[2].Path)
+	require.Equal(t, http.StatusNotFound, records[2].StatusCode)
+	require.ErrorIs(t, records[2].Err, ErrNotSuccessfulResponse)
+	require.True(t, records[1].Time.Before(records[2].Time) || records[1].Time.Equal(records[2].Time))
+
+	// the journal is off by default
+	require.Nil(t, NewHeimdallClient(srv.URL).RecentRequests())
+}
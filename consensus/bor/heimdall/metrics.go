@@ -2,6 +2,7 @@ package heimdall
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/metrics"
@@ -10,6 +11,7 @@ import (
 type (
 	requestTypeKey struct{}
 	requestType    string
+	callerTagKey   struct{}
 
 	meter struct {
 		request map[bool]metrics.Meter // map[isSuccessful]metrics.Meter
@@ -20,13 +22,21 @@ type (
 const (
 	stateSyncRequest          requestType = "state-sync"
 	spanRequest               requestType = "span"
+	spanListRequest           requestType = "span-list"
 	checkpointRequest         requestType = "checkpoint"
 	checkpointCountRequest    requestType = "checkpoint-count"
+	checkpointBufferRequest   requestType = "checkpoint-buffer"
 	milestoneRequest          requestType = "milestone"
 	milestoneCountRequest     requestType = "milestone-count"
 	milestoneNoAckRequest     requestType = "milestone-no-ack"
 	milestoneLastNoAckRequest requestType = "milestone-last-no-ack"
+	milestoneNoAckListRequest requestType = "milestone-no-ack-list"
 	milestoneIDRequest        requestType = "milestone-id"
+	milestoneSignersRequest   requestType = "milestone-signers"
+	milestoneParamsRequest    requestType = "milestone-params"
+	latestBlockRequest        requestType = "latest-block"
+	statusRequest             requestType = "status"
+	chainManagerParamsRequest requestType = "chain-manager-params"
 )
 
 func withRequestType(ctx context.Context, reqType requestType) context.Context {
@@ -38,6 +48,21 @@ func getRequestType(ctx context.Context) (requestType, bool) {
 	return reqType, ok
 }
 
+// otherCallerTag replaces the caller tags which aren't set with WithCallerTags
+const otherCallerTag = "other"
+
+// untypedRequest labels the decode metrics of the requests without a request type,
+// like the health checks
+const untypedRequest = "untyped"
+
+// WithCallerTag tags the heimdall requests sent with the context with the calling
+// subsystem, e.g. "whitelist" or "sync". They are then also recorded in the
+// client/requests/<type>/<tag>/{valid,invalid} counters, and in the duration timer
+// of the tag if the metrics are enabled.
+func WithCallerTag(ctx context.Context, tag string) context.Context {
+	return context.WithValue(ctx, callerTagKey{}, tag)
+}
+
 var (
 	requestMeters = map[requestType]meter{
 		stateSyncRequest: {
@@ -54,6 +79,13 @@ var (
 			},
 			timer: metrics.NewRegisteredTimer("client/requests/span/duration", nil),
 		},
+		spanListRequest: {
+			request: map[bool]metrics.Meter{
+				true:  metrics.NewRegisteredMeter("client/requests/spanlist/valid", nil),
+				false: metrics.NewRegisteredMeter("client/requests/spanlist/invalid", nil),
+			},
+			timer: metrics.NewRegisteredTimer("client/requests/spanlist/duration", nil),
+		},
 		checkpointRequest: {
 			request: map[bool]metrics.Meter{
 				true:  metrics.NewRegisteredMeter("client/requests/checkpoint/valid", nil),
@@ -96,6 +128,13 @@ var (
 			},
 			timer: metrics.NewRegisteredTimer("client/requests/milestonelastnoack/duration", nil),
 		},
+		milestoneNoAckListRequest: {
+			request: map[bool]metrics.Meter{
+				true:  metrics.NewRegisteredMeter("client/requests/milestonenoacklist/valid", nil),
+				false: metrics.NewRegisteredMeter("client/requests/milestonenoacklist/invalid", nil),
+			},
+			timer: metrics.NewRegisteredTimer("client/requests/milestonenoacklist/duration", nil),
+		},
 		milestoneIDRequest: {
 			request: map[bool]metrics.Meter{
 				true:  metrics.NewRegisteredMeter("client/requests/milestoneid/valid", nil),
@@ -103,6 +142,48 @@ var (
 			},
 			timer: metrics.NewRegisteredTimer("client/requests/milestoneid/duration", nil),
 		},
+		milestoneSignersRequest: {
+			request: map[bool]metrics.Meter{
+				true:  metrics.NewRegisteredMeter("client/requests/milestonesigners/valid", nil),
+				false: metrics.NewRegisteredMeter("client/requests/milestonesigners/invalid", nil),
+			},
+			timer: metrics.NewRegisteredTimer("client/requests/milestonesigners/duration", nil),
+		},
+		checkpointBufferRequest: {
+			request: map[bool]metrics.Meter{
+				true:  metrics.NewRegisteredMeter("client/requests/checkpointbuffer/valid", nil),
+				false: metrics.NewRegisteredMeter("client/requests/checkpointbuffer/invalid", nil),
+			},
+			timer: metrics.NewRegisteredTimer("client/requests/checkpointbuffer/duration", nil),
+		},
+		milestoneParamsRequest: {
+			request: map[bool]metrics.Meter{
+				true:  metrics.NewRegisteredMeter("client/requests/milestoneparams/valid", nil),
+				false: metrics.NewRegisteredMeter("client/requests/milestoneparams/invalid", nil),
+			},
+			timer: metrics.NewRegisteredTimer("client/requests/milestoneparams/duration", nil),
+		},
+		latestBlockRequest: {
+			request: map[bool]metrics.Meter{
+				true:  metrics.NewRegisteredMeter("client/requests/latestblock/valid", nil),
+				false: metrics.NewRegisteredMeter("client/requests/latestblock/invalid", nil),
+			},
+			timer: metrics.NewRegisteredTimer("client/requests/latestblock/duration", nil),
+		},
+		statusRequest: {
+			request: map[bool]metrics.Meter{
+				true:  metrics.NewRegisteredMeter("client/requests/status/valid", nil),
+				false: metrics.NewRegisteredMeter("client/requests/status/invalid", nil),
+			},
+			timer: metrics.NewRegisteredTimer("client/requests/status/duration", nil),
+		},
+		chainManagerParamsRequest: {
+			request: map[bool]metrics.Meter{
+				true:  metrics.NewRegisteredMeter("client/requests/chainmanagerparams/valid", nil),
+				false: metrics.NewRegisteredMeter("client/requests/chainmanagerparams/invalid", nil),
+			},
+			timer: metrics.NewRegisteredTimer("client/requests/chainmanagerparams/duration", nil),
+		},
 	}
 )
 
@@ -120,3 +201,107 @@ func sendMetrics(ctx context.Context, start time.Time, isSuccessful bool) {
 	meters.request[isSuccessful].Mark(1)
 	meters.timer.Update(time.Since(start))
 }
+
+// sendCallerMetrics records the request in the metrics of its caller tag, if any
+func (h *HeimdallClient) sendCallerMetrics(ctx context.Context, start time.Time, isSuccessful bool) {
+	tag, ok := ctx.Value(callerTagKey{}).(string)
+	if !ok {
+		return
+	}
+
+	reqType, ok := getRequestType(ctx)
+	if !ok {
+		return
+	}
+
+	if _, allowed := h.callerTags[tag]; !allowed {
+		tag = otherCallerTag
+	}
+
+	name := "client/requests/" + strings.ReplaceAll(string(reqType), "-", "") + "/" + tag
+
+	status := "invalid"
+	if isSuccessful {
+		status = "valid"
+	}
+
+	h.metrics.counter(name + "/" + status).Inc(1)
+	metrics.GetOrRegisterTimer(name+"/duration", h.metrics.registry).UpdateSince(start)
+}
+
+// sendDecodeMetrics records the time spent decoding the response in the histogram of
+// its request type, telling slow decoding apart from slow responses. Like the other
+// client metrics, it is always active in the registry given with WithMetricsRegistry.
+func (h *HeimdallClient) sendDecodeMetrics(ctx context.Context, start time.Time) {
+	endpoint := untypedRequest
+	if reqType, ok := getRequestType(ctx); ok {
+		endpoint = strings.ReplaceAll(string(reqType), "-", "")
+	}
+
+	h.metrics.histogram("client/requests/" + endpoint + "/decode").Update(time.Since(start).Nanoseconds())
+}
+
+// clientMetrics holds the metrics of a client which are not per request type. They
+// are registered in the registry given with WithMetricsRegistry, and are always
+// active there, or in the default registry if the metrics are enabled.
+type clientMetrics struct {
+	registry metrics.Registry
+	forced   bool
+
+	decodeFailures metrics.Counter
+	inFlight       metrics.Gauge
+	connWait       metrics.Gauge // of the last request, in nanoseconds
+	poolExhausted  metrics.Counter
+}
+
+func newClientMetrics(registry metrics.Registry) *clientMetrics {
+	m := &clientMetrics{
+		registry: registry,
+		forced:   registry != nil,
+	}
+
+	if m.registry == nil {
+		m.registry = metrics.DefaultRegistry
+	}
+
+	m.decodeFailures = m.counter("client/requests/decode/failures")
+	m.inFlight = m.gauge("client/requests/inflight")
+	m.connWait = m.gauge("client/requests/connwait")
+	m.poolExhausted = m.counter("client/requests/pool/exhausted")
+
+	return m
+}
+
+func (m *clientMetrics) counter(name string) metrics.Counter {
+	if m.forced {
+		return metrics.GetOrRegisterCounterForced(name, m.registry)
+	}
+
+	return metrics.GetOrRegisterCounter(name, m.registry)
+}
+
+// the reservoir size and alpha of the histogram samples, those of the timers
+const (
+	sampleReservoirSize = 1028
+	sampleAlpha         = 0.015
+)
+
+func (m *clientMetrics) histogram(name string) metrics.Histogram {
+	if m.forced {
+		return m.registry.GetOrRegister(name, func() metrics.Histogram {
+			return metrics.NewHistogramForced(metrics.NewExpDecaySampleForced(sampleReservoirSize, sampleAlpha))
+		}).(metrics.Histogram)
+	}
+
+	return metrics.GetOrRegisterHistogramLazy(name, m.registry, func() metrics.Sample {
+		return metrics.NewExpDecaySample(sampleReservoirSize, sampleAlpha)
+	})
+}
+
+func (m *clientMetrics) gauge(name string) metrics.Gauge {
+	if m.forced {
+		return m.registry.GetOrRegister(name, func() metrics.Gauge { return new(metrics.StandardGauge) }).(metrics.Gauge)
+	}
+
+	return metrics.GetOrRegisterGauge(name, m.registry)
+}
@@ -0,0 +1,46 @@
+package heimdall
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// the histograms are active in the registry of the client, whether the metrics are
+// enabled or not
+func TestDecodeDurationMetrics(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeMilestone(w, 1)
+	}))
+	defer srv.Close()
+
+	registry := metrics.NewRegistry()
+
+	client := NewHeimdallClient(srv.URL, WithMetricsRegistry(registry))
+	defer client.Close()
+
+	_, err := client.FetchMilestone(context.Background())
+	require.NoError(t, err)
+
+	histogram, ok := registry.Get("client/requests/milestone/decode").(metrics.Histogram)
+	require.True(t, ok, "expect the decode histogram of the milestones to be registered")
+	require.Equal(t, int64(1), histogram.Snapshot().Count())
+
+	// the requests without a type are recorded too
+	u, err := milestoneURL(srv.URL)
+	require.NoError(t, err)
+
+	_, err = fetchWithRetry[struct{}](context.Background(), client, u)
+	require.NoError(t, err)
+
+	histogram, ok = registry.Get("client/requests/untyped/decode").(metrics.Histogram)
+	require.True(t, ok, "expect the decode histogram of the untyped requests to be registered")
+	require.Equal(t, int64(1), histogram.Snapshot().Count())
+}
@@ -0,0 +1,103 @@
+package heimdall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/milestone"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ErrMilestoneMismatch is returned if the latest milestone doesn't match the
+// milestone at the index reported by the milestone count
+var ErrMilestoneMismatch = errors.New("latest milestone doesn't match the milestone count")
+
+const milestoneConsistencyAttempts = 3
+
+// FetchLatestMilestoneConsistent fetches the latest milestone and verifies that it is
+// the milestone numbered by the milestone count. A lagging heimdall replica can serve
+// a count and a latest milestone which disagree, in which case the check is retried
+// a bounded number of times before failing with ErrMilestoneMismatch.
+func (h *HeimdallClient) FetchLatestMilestoneConsistent(ctx context.Context) (*milestone.Milestone, error) {
+	for attempt := 1; ; attempt++ {
+		count, err := h.FetchMilestoneCount(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		latest, err := h.FetchMilestone(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		numbered, err := h.FetchMilestoneByNumber(ctx, count)
+		if err != nil {
+			return nil, err
+		}
+
+		if sameMilestone(latest, numbered) {
+			return latest, nil
+		}
+
+		if attempt >= milestoneConsistencyAttempts {
+			return nil, fmt.Errorf("%w: count %d, attempts %d", ErrMilestoneMismatch, count, attempt)
+		}
+
+		log.Warn("Latest milestone doesn't match the milestone count, retrying", "count", count, "attempt", attempt)
+
+		if err := h.wait(ctx, h.retryInterval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// IsBlockFinalized reports whether the block is covered by the latest milestone. It
+// is not before the first milestone, reported with WithNoDataYetOn404.
+func (h *HeimdallClient) IsBlockFinalized(ctx context.Context, blockNumber uint64) (bool, error) {
+	latest, err := h.FetchMilestone(ctx)
+	if errors.Is(err, ErrNoDataYet) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	if latest.EndBlock == nil {
+		return false, nil
+	}
+
+	return new(big.Int).SetUint64(blockNumber).Cmp(latest.EndBlock) <= 0, nil
+}
+
+// wait blocks for the given duration, unless the context is done or the client is closed
+func (h *HeimdallClient) wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-h.closeCh:
+		return ErrShutdownDetected
+	case <-timer.C:
+		return nil
+	}
+}
+
+func sameMilestone(a, b *milestone.Milestone) bool {
+	return a.Hash == b.Hash &&
+		sameBigInt(a.StartBlock, b.StartBlock) &&
+		sameBigInt(a.EndBlock, b.EndBlock)
+}
+
+func sameBigInt(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Cmp(b) == 0
+}
@@ -1,9 +1,12 @@
 package milestone
 
 import (
+	"encoding/json"
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/internal/numbers"
 )
 
 // milestone defines a response object type of bor milestone
@@ -11,9 +14,45 @@ type Milestone struct {
 	Proposer   common.Address `json:"proposer"`
 	StartBlock *big.Int       `json:"start_block"`
 	EndBlock   *big.Int       `json:"end_block"`
-	Hash       common.Hash    `json:"hash"`
+	Hash       common.Hash    `json:"hash"` // decoding fails unless a 0x prefixed 32 bytes hex string
 	BorChainID string         `json:"bor_chain_id"`
 	Timestamp  uint64         `json:"timestamp"`
+
+	// MilestoneID is only served by newer Heimdall versions, used for the no-ack
+	// tracking
+	MilestoneID string `json:"milestone_id"`
+}
+
+// UnmarshalJSON decodes the block numbers from either numbers or strings, as served
+// by different Heimdall versions
+func (m *Milestone) UnmarshalJSON(data []byte) error {
+	type plain Milestone
+
+	var decoded struct {
+		plain
+		StartBlock json.RawMessage `json:"start_block"`
+		EndBlock   json.RawMessage `json:"end_block"`
+	}
+
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	startBlock, err := numbers.ParseBigInt(decoded.StartBlock)
+	if err != nil {
+		return fmt.Errorf("decoding milestone start block: %w", err)
+	}
+
+	endBlock, err := numbers.ParseBigInt(decoded.EndBlock)
+	if err != nil {
+		return fmt.Errorf("decoding milestone end block: %w", err)
+	}
+
+	*m = Milestone(decoded.plain)
+	m.StartBlock = startBlock
+	m.EndBlock = endBlock
+
+	return nil
 }
 
 type MilestoneResponse struct {
@@ -21,10 +60,47 @@ type MilestoneResponse struct {
 	Result Milestone `json:"result"`
 }
 
+// MilestoneSigner is a validator which signed a milestone, with its voting power
+type MilestoneSigner struct {
+	ID          uint64         `json:"ID"`
+	Signer      common.Address `json:"signer"`
+	VotingPower int64          `json:"power"`
+}
+
+type MilestoneSignersResponse struct {
+	Height string            `json:"height"`
+	Result []MilestoneSigner `json:"result"`
+}
+
 type MilestoneCount struct {
 	Count int64 `json:"count"`
 }
 
+// UnmarshalJSON decodes the count from either the nested {"count": 5} object or a
+// bare number or string, as served by different Heimdall versions
+func (m *MilestoneCount) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '{' {
+		var nested struct {
+			Count json.RawMessage `json:"count"`
+		}
+
+		if err := json.Unmarshal(data, &nested); err != nil {
+			return err
+		}
+
+		data = nested.Count
+	}
+
+	count, err := numbers.ParseCount(data)
+	if err != nil {
+		return err
+	}
+
+	m.Count = count
+
+	return nil
+}
+
 type MilestoneCountResponse struct {
 	Height string         `json:"height"`
 	Result MilestoneCount `json:"result"`
@@ -48,6 +124,16 @@ type MilestoneNoAckResponse struct {
 	Result MilestoneNoAck `json:"result"`
 }
 
+// MilestoneNoAckList holds the IDs of the no-ack-milestones in a range
+type MilestoneNoAckList struct {
+	Result []string `json:"result"`
+}
+
+type MilestoneNoAckListResponse struct {
+	Height string             `json:"height"`
+	Result MilestoneNoAckList `json:"result"`
+}
+
 type MilestoneID struct {
 	Result bool `json:"result"`
 }
@@ -56,3 +142,54 @@ type MilestoneIDResponse struct {
 	Height string      `json:"height"`
 	Result MilestoneID `json:"result"`
 }
+
+// MilestoneParams are the parameters of the milestones, which bor whitelists with
+// the same cadence
+type MilestoneParams struct {
+	MilestoneLength uint64 `json:"milestone_length"`
+	SprintLength    uint64 `json:"sprint_length"`
+}
+
+// UnmarshalJSON decodes the parameters from either numbers or strings, as served by
+// different Heimdall versions
+func (p *MilestoneParams) UnmarshalJSON(data []byte) error {
+	var decoded struct {
+		MilestoneLength json.RawMessage `json:"milestone_length"`
+		SprintLength    json.RawMessage `json:"sprint_length"`
+	}
+
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	milestoneLength, err := parseLength(decoded.MilestoneLength)
+	if err != nil {
+		return fmt.Errorf("decoding milestone length: %w", err)
+	}
+
+	sprintLength, err := parseLength(decoded.SprintLength)
+	if err != nil {
+		return fmt.Errorf("decoding sprint length: %w", err)
+	}
+
+	p.MilestoneLength = milestoneLength
+	p.SprintLength = sprintLength
+
+	return nil
+}
+
+// parseLength decodes a non-negative length serialized either as a number or as a
+// string
+func parseLength(data []byte) (uint64, error) {
+	length, err := numbers.ParseCount(data)
+	if err == nil && length < 0 {
+		err = fmt.Errorf("negative length %d", length)
+	}
+
+	return uint64(length), err
+}
+
+type MilestoneParamsResponse struct {
+	Height string          `json:"height"`
+	Result MilestoneParams `json:"result"`
+}
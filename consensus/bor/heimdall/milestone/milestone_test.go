@@ -0,0 +1,153 @@
+package milestone
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMilestoneCountUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "nested object", body: `{"height":"0","result":{"count":5}}`},
+		{name: "nested string", body: `{"height":"0","result":{"count":"5"}}`},
+		{name: "bare number", body: `{"height":"0","result":5}`},
+		{name: "bare string", body: `{"height":"0","result":"5"}`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var response MilestoneCountResponse
+
+			require.NoError(t, json.Unmarshal([]byte(test.body), &response))
+			require.Equal(t, int64(5), response.Result.Count)
+		})
+	}
+
+	var response MilestoneCountResponse
+	require.Error(t, json.Unmarshal([]byte(`{"result":"five"}`), &response))
+
+	// a null count decodes to 0, like before the string counts were supported
+	require.NoError(t, json.Unmarshal([]byte(`{"height":"0","result":{"count":null}}`), &response))
+	require.Zero(t, response.Result.Count)
+}
+
+func TestMilestoneHashUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		hash  string
+		valid bool
+	}{
+		{name: "valid", hash: `"0x0000000000000000000000000000000000000000000000000000000000000001"`, valid: true},
+		{name: "without prefix", hash: `"0000000000000000000000000000000000000000000000000000000000000001"`},
+		{name: "too short", hash: `"0x01"`},
+		{name: "too long", hash: `"0x000000000000000000000000000000000000000000000000000000000000000001"`},
+		{name: "not hex", hash: `"0xzz00000000000000000000000000000000000000000000000000000000000001"`},
+		{name: "empty", hash: `""`},
+		{name: "number", hash: `1`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var m Milestone
+
+			err := json.Unmarshal([]byte(`{"start_block":1,"end_block":2,"hash":`+test.hash+`}`), &m)
+			if !test.valid {
+				require.Error(t, err, "expect an invalid hash to fail decoding rather than produce a zero hash")
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, common.HexToHash("0x1"), m.Hash)
+		})
+	}
+}
+
+func TestMilestoneBlockNumbersUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		body       string
+		start, end *big.Int
+	}{
+		{name: "numbers", body: `{"start_block":256,"end_block":512}`, start: big.NewInt(256), end: big.NewInt(512)},
+		{name: "strings", body: `{"start_block":"256","end_block":"512"}`, start: big.NewInt(256), end: big.NewInt(512)},
+		{name: "mixed", body: `{"start_block":256,"end_block":"512"}`, start: big.NewInt(256), end: big.NewInt(512)},
+		{name: "missing and null", body: `{"end_block":null}`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var decoded Milestone
+
+			require.NoError(t, json.Unmarshal([]byte(test.body), &decoded))
+			require.Equal(t, test.start, decoded.StartBlock)
+			require.Equal(t, test.end, decoded.EndBlock)
+		})
+	}
+
+	var decoded Milestone
+	require.Error(t, json.Unmarshal([]byte(`{"end_block":"five"}`), &decoded))
+}
+
+func TestMilestoneIDUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	var response MilestoneResponse
+
+	body := `{"height":"0","result":{"proposer":"0x0000000000000000000000000000000000000001","start_block":"256","end_block":512,` +
+		`"hash":"0x0000000000000000000000000000000000000000000000000000000000000001","bor_chain_id":"137","timestamp":1,` +
+		`"milestone_id":"b1a9a2d7-5a2d-4e0b-8f73-0e5c1b1f6f2a - 0x0000000000000000000000000000000000000000000000000000000000000001"}}`
+
+	require.NoError(t, json.Unmarshal([]byte(body), &response))
+	require.Equal(t, "b1a9a2d7-5a2d-4e0b-8f73-0e5c1b1f6f2a - 0x0000000000000000000000000000000000000000000000000000000000000001", response.Result.MilestoneID)
+	require.Equal(t, big.NewInt(256), response.Result.StartBlock)
+	require.Equal(t, big.NewInt(512), response.Result.EndBlock)
+	require.Equal(t, common.HexToHash("0x1"), response.Result.Hash)
+	require.Equal(t, "137", response.Result.BorChainID)
+
+	// older versions don't serve it
+	require.NoError(t, json.Unmarshal([]byte(`{"height":"0","result":{"start_block":1,"end_block":2}}`), &response))
+	require.Empty(t, response.Result.MilestoneID)
+}
+
+func TestMilestoneParamsUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	for _, body := range []string{
+		`{"milestone_length":12,"sprint_length":16}`,
+		`{"milestone_length":"12","sprint_length":"16"}`,
+	} {
+		var params MilestoneParams
+
+		require.NoError(t, json.Unmarshal([]byte(body), &params), body)
+		require.Equal(t, MilestoneParams{MilestoneLength: 12, SprintLength: 16}, params)
+	}
+
+	var params MilestoneParams
+	require.Error(t, json.Unmarshal([]byte(`{"milestone_length":"twelve"}`), &params))
+	require.Error(t, json.Unmarshal([]byte(`{"sprint_length":-16}`), &params))
+}
@@ -0,0 +1,189 @@
+package heimdall
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/milestone"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testMilestone returns the n-th milestone of a chain with 16 blocks long milestones
+func testMilestone(n int64) milestone.Milestone {
+	return milestone.Milestone{
+		StartBlock: big.NewInt((n-1)*16 + 1),
+		EndBlock:   big.NewInt(n * 16),
+		Hash:       common.BigToHash(big.NewInt(n)),
+		BorChainID: "15001",
+	}
+}
+
+func writeMilestone(w http.ResponseWriter, n int64) {
+	_ = json.NewEncoder(w).Encode(milestone.MilestoneResponse{Height: "0", Result: testMilestone(n)})
+}
+
+func TestFetchLatestMilestoneConsistent(t *testing.T) {
+	t.Parallel()
+
+	// The replica serving the latest milestone lags behind for the first calls
+	newServer := func(lagging int32) (*httptest.Server, *atomic.Int32) {
+		var latestCalls atomic.Int32
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/milestone/count", func(w http.ResponseWriter, _ *http.Request) {
+			_ = json.NewEncoder(w).Encode(milestone.MilestoneCountResponse{Height: "0", Result: milestone.MilestoneCount{Count: 3}})
+		})
+		mux.HandleFunc("/milestone/latest", func(w http.ResponseWriter, _ *http.Request) {
+			if latestCalls.Add(1) <= lagging {
+				writeMilestone(w, 2)
+				return
+			}
+
+			writeMilestone(w, 3)
+		})
+		mux.HandleFunc("/milestone/3", func(w http.ResponseWriter, _ *http.Request) {
+			writeMilestone(w, 3)
+		})
+
+		srv := httptest.NewServer(mux)
+		t.Cleanup(srv.Close)
+
+		return srv, &latestCalls
+	}
+
+	t.Run("converge", func(t *testing.T) {
+		srv, latestCalls := newServer(1)
+
+		client := NewHeimdallClient(srv.URL)
+		client.retryInterval = 10 * time.Millisecond
+
+		result, err := client.FetchLatestMilestoneConsistent(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, big.NewInt(48), result.EndBlock)
+		require.Equal(t, int32(2), latestCalls.Load(), "expect one retry after the mismatch")
+	})
+
+	t.Run("never converge", func(t *testing.T) {
+		srv, latestCalls := newServer(milestoneConsistencyAttempts)
+
+		client := NewHeimdallClient(srv.URL)
+		client.retryInterval = 10 * time.Millisecond
+
+		_, err := client.FetchLatestMilestoneConsistent(context.Background())
+		require.ErrorIs(t, err, ErrMilestoneMismatch)
+		require.Equal(t, int32(milestoneConsistencyAttempts), latestCalls.Load())
+	})
+}
+
+func TestIsBlockFinalized(t *testing.T) {
+	t.Parallel()
+
+	var found atomic.Bool
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if !found.Load() {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		// ends at block 160
+		writeMilestone(w, 10)
+	}))
+	defer srv.Close()
+
+	client := NewHeimdallClient(srv.URL, WithNoDataYetOn404())
+	defer client.Close()
+
+	finalized, err := client.IsBlockFinalized(context.Background(), 1)
+	require.NoError(t, err)
+	require.False(t, finalized, "expect no block finalized before the first milestone")
+
+	found.Store(true)
+
+	for block, expected := range map[uint64]bool{0: true, 1: true, 159: true, 160: true, 161: false, 1000: false} {
+		finalized, err := client.IsBlockFinalized(context.Background(), block)
+		require.NoError(t, err)
+		require.Equal(t, expected, finalized, "block %d", block)
+	}
+}
+
+func TestFetchMilestoneSigners(t *testing.T) {
+	t.Parallel()
+
+	const milestoneID = "17ce48fe-0a18-41a8-ab7c-2e5e4ed3d6e0 - 0x1"
+
+	signers := []milestone.MilestoneSigner{
+		{ID: 1, Signer: common.HexToAddress("0x1"), VotingPower: 10},
+		{ID: 2, Signer: common.HexToAddress("0x2"), VotingPower: 20},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/milestone/signers/"+milestoneID {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(milestone.MilestoneSignersResponse{Height: "0", Result: signers})
+	}))
+	defer srv.Close()
+
+	got, err := NewHeimdallClient(srv.URL).FetchMilestoneSigners(context.Background(), milestoneID)
+	require.NoError(t, err)
+	require.Equal(t, signers, got)
+}
+
+func TestFetchMilestoneParams(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != fetchMilestoneParams {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"height":"0","result":{"milestone_length":"12","sprint_length":16}}`))
+	}))
+	defer srv.Close()
+
+	params, err := NewHeimdallClient(srv.URL).FetchMilestoneParams(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, &milestone.MilestoneParams{MilestoneLength: 12, SprintLength: 16}, params)
+}
+
+func TestFetchNoAckMilestones(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != fetchNoAckMilestoneList {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if r.URL.Query().Get("from") != "10" || r.URL.Query().Get("to") != "20" {
+			_, _ = w.Write([]byte(`{"height":"0","result":{"result":[]}}`))
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"height":"0","result":{"result":["milestone-11","milestone-17"]}}`))
+	}))
+	defer srv.Close()
+
+	client := NewHeimdallClient(srv.URL)
+	defer client.Close()
+
+	ids, err := client.FetchNoAckMilestones(context.Background(), 10, 20)
+	require.NoError(t, err)
+	require.Equal(t, []string{"milestone-11", "milestone-17"}, ids)
+
+	ids, err = client.FetchNoAckMilestones(context.Background(), 30, 40)
+	require.NoError(t, err)
+	require.Empty(t, ids)
+}
@@ -0,0 +1,506 @@
+package heimdall
+
+import (
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// Option configures an optional behaviour of the HeimdallClient
+type Option func(*HeimdallClient)
+
+// WithRetryBudget bounds each fetch to the given number of attempts. When the
+// context carries a deadline, the per attempt timeout is derived from the time left
+// divided across the remaining attempts instead of the fixed default, so that the
+// whole retry sequence stays within the deadline of the caller.
+func WithRetryBudget(attempts int) Option {
+	return func(h *HeimdallClient) {
+		h.budgetAttempts = attempts
+	}
+}
+
+// WithoutKeepAlives disables the reuse of connections, so that each request is sent
+// over a fresh connection. Behind a load balancer this spreads the requests across
+// the backends instead of pinning them to a single one.
+func WithoutKeepAlives() Option {
+	return func(h *HeimdallClient) {
+		h.transportCfg.disableKeepAlives = true
+	}
+}
+
+// WithStaleDNSFallback dials the last address the heimdall host was connected at if
+// resolving it fails, so that the client keeps working through DNS outages. If there
+// is no such address, or it can't be dialed, ErrDNSResolutionFailed is returned.
+func WithStaleDNSFallback() Option {
+	return func(h *HeimdallClient) {
+		h.transportCfg.staleDNSFallback = true
+	}
+}
+
+// WithIdleConnTimeout closes the connections left idle for longer than the given
+// duration, instead of the default 90 seconds
+func WithIdleConnTimeout(timeout time.Duration) Option {
+	return func(h *HeimdallClient) {
+		h.transportCfg.idleConnTimeout = timeout
+	}
+}
+
+// WithMaxConnLifetime stops reusing the connections older than the given duration,
+// so that connections silently dropped by a load balancer are recycled before they
+// fail a request.
+func WithMaxConnLifetime(lifetime time.Duration) Option {
+	return func(h *HeimdallClient) {
+		h.transportCfg.maxConnLifetime = lifetime
+	}
+}
+
+// WithMaxConnsPerHost bounds the connections to each host, the requests beyond
+// them waiting for one to be available, see WithPoolWaitLimit
+func WithMaxConnsPerHost(conns int) Option {
+	return func(h *HeimdallClient) {
+		h.transportCfg.maxConnsPerHost = conns
+	}
+}
+
+// WithPoolWaitLimit fails the attempts waiting longer than the limit for a
+// connection, idle or dialed, with ErrPoolExhausted instead of blocking until one is
+// available. The error isn't retried, surfacing the saturation to the caller.
+func WithPoolWaitLimit(limit time.Duration) Option {
+	return func(h *HeimdallClient) {
+		h.poolWaitLimit = limit
+	}
+}
+
+// WithRequiredFieldValidation rejects checkpoints and milestones which lack the
+// block bounds or the root hash, rather than returning their zero values.
+func WithRequiredFieldValidation() Option {
+	return func(h *HeimdallClient) {
+		h.requireFields = true
+	}
+}
+
+// WithProxy sends all the requests through the given proxy. Both http(s) and socks5
+// proxy urls are supported. Without it, the proxy is taken from the HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY environment variables.
+func WithProxy(proxy *url.URL) Option {
+	return func(h *HeimdallClient) {
+		h.transportCfg.proxy = proxy
+	}
+}
+
+// WithMetricsRegistry registers the metrics of the client in the given registry,
+// where they are collected regardless of the global metrics switch.
+func WithMetricsRegistry(registry metrics.Registry) Option {
+	return func(h *HeimdallClient) {
+		h.metricsRegistry = registry
+	}
+}
+
+// WithDecodeFailureHook calls hook whenever a response body can't be decoded, with
+// the request path, the start of the body and the decoding error.
+func WithDecodeFailureHook(hook func(path string, sample []byte, err error)) Option {
+	return func(h *HeimdallClient) {
+		h.onDecodeFailure = hook
+	}
+}
+
+// WithDecodeRetries retries the response bodies which couldn't be decoded for being
+// truncated, like by a flaky connection, up to the given number of times. Complete
+// but malformed bodies aren't retried. Without it, decode failures are retried like
+// any other failure.
+func WithDecodeRetries(retries int) Option {
+	return func(h *HeimdallClient) {
+		h.decodeRetries = retries
+	}
+}
+
+// WithAcceptedStatusCodes sets the response status codes treated as a success,
+// instead of 200 and 204. Gateways may serve valid data with other codes, like 206.
+// The body of a 204 response is always empty.
+func WithAcceptedStatusCodes(codes ...int) Option {
+	return func(h *HeimdallClient) {
+		h.acceptedStatus = make(map[int]struct{}, len(codes))
+
+		for _, code := range codes {
+			h.acceptedStatus[code] = struct{}{}
+		}
+	}
+}
+
+// WithStaleCountFallback makes FetchCheckpointCount and FetchMilestoneCount return
+// the last count fetched within maxAge if fetching a fresh one fails, so that a
+// transient failure doesn't stall the polling callers. While a last count can be
+// served, the fetches are bounded to a few attempts, even without a retry budget.
+// The FetchMeta of the context is then flagged stale.
+func WithStaleCountFallback(maxAge time.Duration) Option {
+	return func(h *HeimdallClient) {
+		h.lastCounts = newStaleCounts(maxAge)
+	}
+}
+
+// WithRegressionGuard rejects latest checkpoints and milestones ending before the
+// last ones returned with ErrLatestRegressed. The last ones are kept in the given
+// store, so that they persist across restarts, or in memory if it is nil.
+func WithRegressionGuard(store StateStore) Option {
+	return func(h *HeimdallClient) {
+		if store == nil {
+			store = NewMemoryStateStore()
+		}
+
+		h.regression = &regressionGuard{store: store}
+	}
+}
+
+// WithCallerTags sets the caller tags recorded in the per tag request metrics, see
+// WithCallerTag. Other tags are recorded as "other", bounding the number of metrics.
+func WithCallerTags(tags ...string) Option {
+	return func(h *HeimdallClient) {
+		h.callerTags = make(map[string]struct{}, len(tags))
+
+		for _, tag := range tags {
+			h.callerTags[tag] = struct{}{}
+		}
+	}
+}
+
+// WithCircuitBreaker stops sending requests to an endpoint after threshold
+// consecutive failures, failing with ErrCircuitOpen until the cooldown elapsed. Each
+// endpoint has its own breaker, so that a failing endpoint doesn't block the others.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(h *HeimdallClient) {
+		h.breakers = &circuitBreakers{
+			threshold: threshold,
+			cooldown:  cooldown,
+			breakers:  make(map[string]*circuitBreaker),
+		}
+	}
+}
+
+// WithSharedCircuitBreaker makes all the endpoints share a single circuit breaker.
+// It must follow WithCircuitBreaker.
+func WithSharedCircuitBreaker() Option {
+	return func(h *HeimdallClient) {
+		if h.breakers != nil {
+			h.breakers.shared = true
+		}
+	}
+}
+
+// WithResponseCache caches successful responses by url for the given ttl, or for the
+// max-age of their Cache-Control header. Responses marked no-store or no-cache are
+// never cached.
+func WithResponseCache(ttl time.Duration) Option {
+	return func(h *HeimdallClient) {
+		h.cache = newResponseCache(ttl)
+	}
+}
+
+// WithIfModifiedSince fetches the latest checkpoint, milestone and span with the
+// If-Modified-Since header set to the Last-Modified header of the last response, if
+// any. A 304 reply then returns the last response again, sparing its transfer.
+func WithIfModifiedSince() Option {
+	return func(h *HeimdallClient) {
+		h.modified = newModifiedCache()
+	}
+}
+
+// WithPartialResultsOnShutdown makes StateSyncEvents return the events fetched so
+// far along with ErrShutdownDetected when the client is closed during pagination,
+// so that the caller can persist the progress made.
+func WithPartialResultsOnShutdown() Option {
+	return func(h *HeimdallClient) {
+		h.partialOnShutdown = true
+	}
+}
+
+// WithStateSyncBudget bounds each StateSyncEvents call to the given duration overall,
+// across all its pages and their retries. Past it, the events fetched so far are
+// returned along with ErrStateSyncBudgetExceeded, and by StateSyncEventsPages the
+// id to resume from.
+func WithStateSyncBudget(budget time.Duration) Option {
+	return func(h *HeimdallClient) {
+		h.stateSyncBudget = budget
+	}
+}
+
+// WithMaxStateSyncOperations bounds the StateSyncEvents calls paging at once across
+// the client, the others waiting for their turn. Each one fetching many pages, a few
+// subsystems syncing at once would otherwise flood heimdall.
+func WithMaxStateSyncOperations(operations int) Option {
+	return func(h *HeimdallClient) {
+		if operations > 0 {
+			h.stateSyncSlots = make(chan struct{}, operations)
+		}
+	}
+}
+
+// WithStateSyncPaging sets how StateSyncEvents detects the last page, by default
+// StopOnShortPage. An empty page always ends the paging.
+func WithStateSyncPaging(lastPage StateSyncPaging) Option {
+	return func(h *HeimdallClient) {
+		h.lastStateSyncPage = lastPage
+	}
+}
+
+// WithMaxClockSkew rejects checkpoints and milestones timestamped more than skew
+// ahead of the local clock with ErrTimestampInFuture, which hints at a misconfigured
+// or malicious heimdall.
+func WithMaxClockSkew(skew time.Duration) Option {
+	return func(h *HeimdallClient) {
+		h.maxClockSkew = skew
+	}
+}
+
+// WithTransport makes the client send its requests through the given transport,
+// instead of building its own. Sharing one transport across clients shares its
+// connection pool. The transport options are then ignored, and Close leaves the
+// idle connections open for the other clients: closing them is up to the owner.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(h *HeimdallClient) {
+		h.sharedTransport = transport
+	}
+}
+
+// WithDryRun logs the requests instead of sending them, replying with the canned
+// response body of their url path, like "/milestone/latest", or an empty JSON object
+// decoded as the zero value otherwise. It validates the wiring without heimdall.
+func WithDryRun(responses map[string]string) Option {
+	return func(h *HeimdallClient) {
+		h.dryRun = make(map[string]string, len(responses))
+
+		for path, body := range responses {
+			h.dryRun[path] = body
+		}
+	}
+}
+
+// WithURLResolver sends the requests to the heimdall url returned by the resolver
+// instead of the static one, following service discovery changes without restart.
+// The url resolved is cached for the ttl, and kept while the resolver fails; the
+// static url is used until one was resolved.
+func WithURLResolver(resolver URLResolver, ttl time.Duration) Option {
+	return func(h *HeimdallClient) {
+		h.resolver = &urlResolver{resolve: resolver, ttl: ttl}
+	}
+}
+
+// WithConsistencyCheck fetches checkpoints and milestones from the canary heimdall
+// too, failing with ErrConsistencyCheckFailed if its root hash or end block differs
+// from the primary one. It doubles the cost of these requests. The canary is fetched
+// with a few attempts at most, with circuit breakers of its own.
+func WithConsistencyCheck(canaryURL string) Option {
+	return func(h *HeimdallClient) {
+		h.canaryURL = canaryURL
+	}
+}
+
+// WithReplicaURL sends the non-critical reads, the checkpoint and milestone counts
+// and the span list, to a read-only replica heimdall to offload the primary one.
+// The primary heimdall is used if the replica fails, and for all other requests.
+func WithReplicaURL(replicaURL string) Option {
+	return func(h *HeimdallClient) {
+		h.replicaURL = replicaURL
+	}
+}
+
+// WithRetryJitter randomly spreads the wait between retries by up to the given
+// fraction of the retry interval, so that clients failing together don't retry in
+// lockstep. Fractions above 1 are capped at 1.
+func WithRetryJitter(fraction float64) Option {
+	return func(h *HeimdallClient) {
+		if fraction > 1 {
+			fraction = 1
+		}
+
+		h.retryJitter = fraction
+	}
+}
+
+// WithJitterSource sets the source of the random numbers in [0, 1) used for the
+// retry jitter, by default the global math/rand source. Mostly useful for tests.
+func WithJitterSource(random func() float64) Option {
+	return func(h *HeimdallClient) {
+		h.jitterSource = random
+	}
+}
+
+// WithRequestJournal keeps the given number of the most recent requests in memory,
+// returned by RecentRequests
+func WithRequestJournal(size int) Option {
+	return func(h *HeimdallClient) {
+		if size > 0 {
+			h.journal = newRequestJournal(size)
+		}
+	}
+}
+
+// WithBasicAuth sends the given credentials with every request, taking precedence
+// over the userinfo of the heimdall url, which is used otherwise
+func WithBasicAuth(username, password string) Option {
+	return func(h *HeimdallClient) {
+		h.basicAuth = url.UserPassword(username, password)
+	}
+}
+
+// WithSpanLength sets the length of the spans following the zeroth one, used to find
+// the span of a block. It differs across networks, the default is the mainnet one.
+// A zero length is ignored.
+func WithSpanLength(length uint64) Option {
+	return func(h *HeimdallClient) {
+		if length > 0 {
+			h.spanLength = length
+		}
+	}
+}
+
+// WithCountCoalescing batches the checkpoint and milestone count calls arriving within
+// the given window into a single request, delaying the first of them by the window
+func WithCountCoalescing(window time.Duration) Option {
+	return func(h *HeimdallClient) {
+		if window > 0 {
+			h.counts = newCoalescer(window)
+		}
+	}
+}
+
+// WithNoDataYetOn404 makes a 404 reply for the latest checkpoint, milestone or span
+// return ErrNoDataYet right away instead of being retried. Heimdall replies so until
+// the first one exists.
+func WithNoDataYetOn404() Option {
+	return func(h *HeimdallClient) {
+		h.noDataYetOn404 = true
+	}
+}
+
+// WithSpanFetchConcurrency sets the number of spans fetched at once by SpansInRange,
+// 4 by default. A non-positive number is ignored.
+func WithSpanFetchConcurrency(concurrency int) Option {
+	return func(h *HeimdallClient) {
+		if concurrency > 0 {
+			h.spanFetchConcurrency = concurrency
+		}
+	}
+}
+
+// WithCheckpointTipCheck fails fetched checkpoints ending beyond the latest block
+// height reported by FetchStatus with ErrCheckpointAheadOfTip. The status is fetched
+// with a few attempts at most, and the checkpoints are returned unchecked if it can't
+// be.
+func WithCheckpointTipCheck() Option {
+	return func(h *HeimdallClient) {
+		h.checkpointTipCheck = true
+	}
+}
+
+// WithPolicy sets the policy of the requests whose url path matches the pattern, in
+// the syntax of path.Match, like "/*/count" for the count endpoints. The paths are
+// matched with a leading slash and the first matching policy applies.
+func WithPolicy(pattern string, policy Policy) Option {
+	return func(h *HeimdallClient) {
+		h.policies = append(h.policies, pathPolicy{pattern: pattern, policy: policy})
+	}
+}
+
+// WithDefaultQueryParams adds the query parameters to every request, e.g. the API
+// version or the tenant required by a gateway. The parameters of the endpoint take
+// precedence: a default is only added if the endpoint doesn't set the same key.
+func WithDefaultQueryParams(params url.Values) Option {
+	return func(h *HeimdallClient) {
+		h.defaultQuery = make(url.Values, len(params))
+
+		for key, values := range params {
+			h.defaultQuery[key] = append([]string(nil), values...)
+		}
+	}
+}
+
+// WithRequestInterceptor calls the interceptors with every request before it is sent,
+// in order, after those of the previous options. An error aborts the attempt.
+func WithRequestInterceptor(interceptors ...RequestInterceptor) Option {
+	return func(h *HeimdallClient) {
+		h.interceptors = append(h.interceptors, interceptors...)
+	}
+}
+
+// WithSLAViolationHook calls the hook with the path and the response time of every
+// attempt slower than the SLA of its policy, set with WithPolicy. The hook must not
+// block, it is called on the request path.
+func WithSLAViolationHook(hook func(path string, elapsed time.Duration)) Option {
+	return func(h *HeimdallClient) {
+		h.onSLAViolation = hook
+	}
+}
+
+// WithChecksumVerification verifies the response bodies against the digest of their
+// Content-MD5 or Digest header, with the MD5, SHA-256 or SHA-512 algorithm, failing
+// with ErrChecksumMismatch if they differ. Responses without these headers, or
+// decompressed by the transport, aren't verified.
+func WithChecksumVerification() Option {
+	return func(h *HeimdallClient) {
+		h.verifyChecksums = true
+	}
+}
+
+// WithSlowRequestThreshold logs a warning with the path and the response time of
+// every attempt slower than the threshold
+func WithSlowRequestThreshold(threshold time.Duration) Option {
+	return func(h *HeimdallClient) {
+		h.slowRequestThreshold = threshold
+	}
+}
+
+// WithImmediateRetry retries a request failing with one of the given errors once
+// right away, before waiting between the next attempts. Without errors, the
+// connection resets and broken pipes are retried, which are transient when a reused
+// keep-alive connection was closed by the server meanwhile.
+func WithImmediateRetry(errs ...error) Option {
+	return func(h *HeimdallClient) {
+		if len(errs) == 0 {
+			errs = []error{syscall.ECONNRESET, syscall.EPIPE}
+		}
+
+		h.immediateRetryErrors = errs
+	}
+}
+
+// WithRepeatedErrorSuppression logs each error of the retried attempts of a request
+// once, until it changes or the interval elapsed, instead of every few attempts. A
+// summary of the suppressed errors is logged once the request succeeds or gives up.
+func WithRepeatedErrorSuppression(interval time.Duration) Option {
+	return func(h *HeimdallClient) {
+		h.errorLogInterval = interval
+	}
+}
+
+// WithFastRetry retries the first failures of each request with a 5xx status after
+// the given delay instead of the retry interval, for up to the given number of
+// retries. Such failures are answered by the server, often quickly on transient
+// blips, so retrying sooner but not instantly recovers without hammering it.
+func WithFastRetry(delay time.Duration, retries int) Option {
+	return func(h *HeimdallClient) {
+		h.fastRetryDelay = delay
+		h.fastRetryAttempts = retries
+	}
+}
+
+// WithAdaptiveTimeout derives the timeout of each attempt from the recent latencies
+// of heimdall instead of the policies, a percentile of them times a factor within
+// the bounds of the config. It stays tight on a fast network but tolerant when the
+// latency rises. Timeouts set with TimeoutKey take precedence.
+func WithAdaptiveTimeout(config AdaptiveTimeout) Option {
+	return func(h *HeimdallClient) {
+		h.adaptiveTimeout = newAdaptiveTimeout(config)
+	}
+}
+
+// WithDefaultDeadline bounds the requests made with a context without a deadline,
+// like context.Background(), to the given duration including the retries, instead of
+// retrying until they succeed.
+func WithDefaultDeadline(deadline time.Duration) Option {
+	return func(h *HeimdallClient) {
+		h.defaultDeadline = deadline
+	}
+}
@@ -0,0 +1,84 @@
+package heimdall
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Policy bundles the behaviour of the requests to a group of endpoints. Zero fields
+// fall back to the client defaults.
+type Policy struct {
+	// Timeout of each attempt
+	Timeout time.Duration
+
+	// MaxAttempts bounds the attempts, which are unbounded if zero unless a retry
+	// budget is set
+	MaxAttempts int
+
+	// RetryInterval is the wait between attempts
+	RetryInterval time.Duration
+
+	// AcceptedStatusCodes are the response status codes treated as a success
+	AcceptedStatusCodes []int
+
+	// SLA is the response time over which the hook set with WithSLAViolationHook is
+	// called, even if the attempt succeeds. There is none if zero.
+	SLA time.Duration
+}
+
+type pathPolicy struct {
+	pattern string
+	policy  Policy
+}
+
+// PolicyFor returns the policy of the requests to the given url path: the first one
+// set with WithPolicy whose pattern matches it, completed with the client defaults.
+func (h *HeimdallClient) PolicyFor(urlPath string) Policy {
+	var policy Policy
+
+	urlPath = "/" + strings.TrimPrefix(urlPath, "/")
+
+	for _, p := range h.policies {
+		if ok, _ := path.Match(p.pattern, urlPath); ok {
+			policy = p.policy
+			break
+		}
+	}
+
+	if policy.Timeout <= 0 {
+		policy.Timeout = apiHeimdallTimeout
+	}
+
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = h.budgetAttempts
+	}
+
+	if policy.RetryInterval <= 0 {
+		policy.RetryInterval = h.retryInterval
+	}
+
+	if policy.AcceptedStatusCodes == nil {
+		policy.AcceptedStatusCodes = h.defaultAcceptedStatusCodes()
+	}
+
+	return policy
+}
+
+// defaultAcceptedStatusCodes returns the codes set with WithAcceptedStatusCodes, or
+// 200 and 204
+func (h *HeimdallClient) defaultAcceptedStatusCodes() []int {
+	if h.acceptedStatus == nil {
+		return []int{200, 204}
+	}
+
+	codes := make([]int, 0, len(h.acceptedStatus))
+	for code := range h.acceptedStatus {
+		codes = append(codes, code)
+	}
+
+	sort.Ints(codes)
+
+	return codes
+}
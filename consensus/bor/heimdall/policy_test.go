@@ -0,0 +1,141 @@
+package heimdall
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyFor(t *testing.T) {
+	t.Parallel()
+
+	counts := Policy{Timeout: time.Second, MaxAttempts: 2}
+	latest := Policy{RetryInterval: time.Second, AcceptedStatusCodes: []int{200, 206}}
+
+	client := NewHeimdallClient("", WithPolicy("/*/count", counts), WithPolicy("/*/latest", latest), WithPolicy("/bor/latest-span", latest))
+
+	defaults := Policy{
+		Timeout:             apiHeimdallTimeout,
+		RetryInterval:       retryCall,
+		AcceptedStatusCodes: []int{200, 204},
+	}
+
+	tests := []struct {
+		path   string
+		policy Policy
+	}{
+		{path: "/checkpoints/count", policy: Policy{Timeout: time.Second, MaxAttempts: 2, RetryInterval: retryCall, AcceptedStatusCodes: []int{200, 204}}},
+		{path: "/milestone/count", policy: Policy{Timeout: time.Second, MaxAttempts: 2, RetryInterval: retryCall, AcceptedStatusCodes: []int{200, 204}}},
+		{path: "/milestone/latest", policy: Policy{Timeout: apiHeimdallTimeout, RetryInterval: time.Second, AcceptedStatusCodes: []int{200, 206}}},
+		{path: "bor/latest-span", policy: Policy{Timeout: apiHeimdallTimeout, RetryInterval: time.Second, AcceptedStatusCodes: []int{200, 206}}},
+		{path: "/checkpoints/5", policy: defaults},
+		{path: "bor/span/list", policy: defaults},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.policy, client.PolicyFor(test.path), test.path)
+	}
+
+	// the defaults follow the client options
+	client = NewHeimdallClient("", WithRetryBudget(3), WithAcceptedStatusCodes(206, 200))
+	require.Equal(t, Policy{Timeout: apiHeimdallTimeout, MaxAttempts: 3, RetryInterval: retryCall, AcceptedStatusCodes: []int{200, 206}}, client.PolicyFor("/checkpoints/5"))
+}
+
+func TestPolicyApplied(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+
+		if r.URL.Path == fetchMilestone {
+			w.WriteHeader(http.StatusPartialContent)
+			writeMilestone(w, 1)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	client := NewHeimdallClient(srv.URL,
+		WithPolicy("/*/count", Policy{MaxAttempts: 2, RetryInterval: 10 * time.Millisecond}),
+		WithPolicy("/*/latest", Policy{AcceptedStatusCodes: []int{http.StatusPartialContent}}),
+	)
+
+	// the count is given up after the attempts of its policy
+	_, err := client.FetchCheckpointCount(context.Background())
+	require.ErrorIs(t, err, ErrNotSuccessfulResponse)
+	require.Equal(t, int32(2), hits.Load())
+
+	// the latest milestone is accepted with a 206
+	_, err = client.FetchMilestone(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int32(3), hits.Load())
+}
+
+func TestPolicyTimeoutAboveDefault(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(apiHeimdallTimeout + time.Second)
+
+		_, _ = w.Write([]byte(`{"height":"0","result":{"result":7}}`))
+	}))
+	defer srv.Close()
+
+	client := NewHeimdallClient(srv.URL, WithPolicy("/*/count", Policy{Timeout: 2 * apiHeimdallTimeout, MaxAttempts: 1}))
+
+	count, err := client.FetchCheckpointCount(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(7), count)
+}
+
+func TestSLAViolationHook(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == fetchMilestone {
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		writeMilestone(w, 1)
+	}))
+	defer srv.Close()
+
+	type violation struct {
+		path    string
+		elapsed time.Duration
+	}
+
+	violations := make(chan violation, 10)
+
+	client := NewHeimdallClient(srv.URL,
+		WithPolicy("/*/latest", Policy{SLA: 20 * time.Millisecond}),
+		WithSLAViolationHook(func(path string, elapsed time.Duration) {
+			violations <- violation{path: path, elapsed: elapsed}
+		}),
+	)
+
+	// the slow call still succeeds
+	_, err := client.FetchMilestone(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, violations, 1)
+
+	v := <-violations
+	require.Equal(t, fetchMilestone, v.path)
+	require.GreaterOrEqual(t, v.elapsed, 50*time.Millisecond)
+
+	// endpoints without an SLA never violate it
+	_, err = client.FetchMilestoneByNumber(context.Background(), 1)
+	require.NoError(t, err)
+	require.Empty(t, violations)
+}
@@ -0,0 +1,70 @@
+package heimdall
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/checkpoint"
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/milestone"
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/span"
+)
+
+// Provenance tells where and when a result was fetched from, for audit trails
+type Provenance struct {
+	// URL is the url which served the result, like the replica or the primary
+	// heimdall. It is empty if the result was served from memory, like the response
+	// cache or a stale count.
+	URL string
+
+	// Time is when the result was received
+	Time time.Time
+
+	// Attempts is the number of attempts made by all the requests of the fetch
+	Attempts int
+}
+
+// withProvenance calls fetch and returns the provenance of its result, still
+// recording the requests in the fetch meta of the caller if any
+func withProvenance[T any](ctx context.Context, fetch func(ctx context.Context) (T, error)) (T, Provenance, error) {
+	meta := &FetchMeta{parent: fetchMetaFrom(ctx)}
+
+	result, err := fetch(WithFetchMeta(ctx, meta))
+
+	meta.mu.Lock()
+	defer meta.mu.Unlock()
+
+	return result, Provenance{URL: meta.served, Time: meta.servedAt, Attempts: meta.Attempts()}, err
+}
+
+// FetchCheckpointWithProvenance fetches the checkpoint like FetchCheckpoint, along
+// with its provenance
+func (h *HeimdallClient) FetchCheckpointWithProvenance(ctx context.Context, number int64) (*checkpoint.Checkpoint, Provenance, error) {
+	return withProvenance(ctx, func(ctx context.Context) (*checkpoint.Checkpoint, error) {
+		return h.FetchCheckpoint(ctx, number)
+	})
+}
+
+// FetchCheckpointCountWithProvenance fetches the checkpoint count like
+// FetchCheckpointCount, along with its provenance
+func (h *HeimdallClient) FetchCheckpointCountWithProvenance(ctx context.Context) (int64, Provenance, error) {
+	return withProvenance(ctx, h.FetchCheckpointCount)
+}
+
+// FetchMilestoneWithProvenance fetches the latest milestone like FetchMilestone,
+// along with its provenance
+func (h *HeimdallClient) FetchMilestoneWithProvenance(ctx context.Context) (*milestone.Milestone, Provenance, error) {
+	return withProvenance(ctx, h.FetchMilestone)
+}
+
+// FetchMilestoneCountWithProvenance fetches the milestone count like
+// FetchMilestoneCount, along with its provenance
+func (h *HeimdallClient) FetchMilestoneCountWithProvenance(ctx context.Context) (int64, Provenance, error) {
+	return withProvenance(ctx, h.FetchMilestoneCount)
+}
+
+// SpanWithProvenance fetches the span like Span, along with its provenance
+func (h *HeimdallClient) SpanWithProvenance(ctx context.Context, spanID uint64) (*span.HeimdallSpan, Provenance, error) {
+	return withProvenance(ctx, func(ctx context.Context) (*span.HeimdallSpan, error) {
+		return h.Span(ctx, spanID)
+	})
+}
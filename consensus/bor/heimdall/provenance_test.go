@@ -0,0 +1,55 @@
+package heimdall
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvenance(t *testing.T) {
+	t.Parallel()
+
+	t.Run("served by the replica", func(t *testing.T) {
+		t.Parallel()
+
+		primary, _ := newCountServer(t, 1, false)
+		replica, _ := newCountServer(t, 2, false)
+
+		client := NewHeimdallClient(primary.URL, WithReplicaURL(replica.URL))
+
+		start := time.Now()
+
+		count, provenance, err := client.FetchCheckpointCountWithProvenance(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(2), count)
+		require.Equal(t, replica.URL+fetchCheckpointCount, provenance.URL)
+		require.Equal(t, 1, provenance.Attempts)
+		require.False(t, provenance.Time.Before(start))
+
+		_, provenance, err = client.FetchMilestoneWithProvenance(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, primary.URL+fetchMilestone, provenance.URL)
+	})
+
+	t.Run("failover to the primary", func(t *testing.T) {
+		t.Parallel()
+
+		primary, _ := newCountServer(t, 1, false)
+		replica, _ := newCountServer(t, 2, true)
+
+		client := NewHeimdallClient(primary.URL, WithReplicaURL(replica.URL))
+
+		var meta FetchMeta
+
+		count, provenance, err := client.FetchCheckpointCountWithProvenance(WithFetchMeta(context.Background(), &meta))
+		require.NoError(t, err)
+		require.Equal(t, int64(1), count)
+		require.Equal(t, primary.URL+fetchCheckpointCount, provenance.URL)
+		require.Equal(t, 2, provenance.Attempts, "expect the failed replica attempt counted")
+
+		// the meta of the caller still records the requests
+		require.Equal(t, 2, meta.Attempts())
+	})
+}
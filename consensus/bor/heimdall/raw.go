@@ -0,0 +1,51 @@
+package heimdall
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+)
+
+// FetchCheckpointRaw fetches the checkpoint from heimdall and returns the response
+// body without decoding it
+func (h *HeimdallClient) FetchCheckpointRaw(ctx context.Context, number int64) ([]byte, error) {
+	url, err := checkpointURL(h.urlString, number)
+	if err != nil {
+		return nil, err
+	}
+
+	return fetchRawWithRetry(withRequestType(ctx, checkpointRequest), h, url)
+}
+
+// FetchMilestoneRaw fetches the latest milestone from heimdall and returns the
+// response body without decoding it
+func (h *HeimdallClient) FetchMilestoneRaw(ctx context.Context) ([]byte, error) {
+	url, err := milestoneURL(h.urlString)
+	if err != nil {
+		return nil, err
+	}
+
+	return fetchRawWithRetry(withRequestType(ctx, milestoneRequest), h, url)
+}
+
+// SpanRaw fetches the span from heimdall and returns the response body without
+// decoding it
+func (h *HeimdallClient) SpanRaw(ctx context.Context, spanID uint64) ([]byte, error) {
+	url, err := spanURL(h.urlString, spanID)
+	if err != nil {
+		return nil, err
+	}
+
+	return fetchRawWithRetry(withRequestType(ctx, spanRequest), h, url)
+}
+
+// fetchRawWithRetry returns the response body from heimdall with retry. The body is
+// only checked to be valid json, so that it can be stored or relayed verbatim.
+func fetchRawWithRetry(ctx context.Context, h *HeimdallClient, url *url.URL) ([]byte, error) {
+	response, err := fetchWithRetry[json.RawMessage](ctx, h, url)
+	if err != nil {
+		return nil, err
+	}
+
+	return *response, nil
+}
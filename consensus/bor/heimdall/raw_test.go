@@ -0,0 +1,29 @@
+package heimdall
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchRaw(t *testing.T) {
+	t.Parallel()
+
+	const body = `{"height":"0","result":{"proposer":"0x0000000000000000000000000000000000000000","start_block":0,"end_block":512,"root_hash":"0x0000000000000000000000000000000000000000000000000000000000000000","bor_chain_id":"15001","timestamp":0}}`
+
+	srv := newStaticServer(t, body)
+	client := NewHeimdallClient(srv.URL)
+
+	raw, err := client.FetchCheckpointRaw(context.Background(), -1)
+	require.NoError(t, err)
+	require.Equal(t, body, string(raw))
+
+	raw, err = client.FetchMilestoneRaw(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, body, string(raw))
+
+	raw, err = client.SpanRaw(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, body, string(raw))
+}
@@ -0,0 +1,136 @@
+package heimdall
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/checkpoint"
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/milestone"
+)
+
+// ErrLatestRegressed is returned if the latest checkpoint or milestone ends before
+// the last one returned, which hints at a heimdall node rolled back or out of sync
+var ErrLatestRegressed = errors.New("latest checkpoint or milestone regressed")
+
+// StateStore persists the last checkpoint and milestone returned as the latest ones,
+// so that the regression guard enabled with WithRegressionGuard survives restarts.
+// The load methods return nil if nothing was saved yet.
+type StateStore interface {
+	LoadLastCheckpoint() (*checkpoint.Checkpoint, error)
+	SaveLastCheckpoint(cp *checkpoint.Checkpoint) error
+	LoadLastMilestone() (*milestone.Milestone, error)
+	SaveLastMilestone(m *milestone.Milestone) error
+}
+
+// memoryStateStore is the default StateStore, which doesn't outlive the process
+type memoryStateStore struct {
+	mu         sync.Mutex
+	checkpoint *checkpoint.Checkpoint
+	milestone  *milestone.Milestone
+}
+
+// NewMemoryStateStore returns a StateStore keeping the state in memory
+func NewMemoryStateStore() StateStore {
+	return &memoryStateStore{}
+}
+
+func (s *memoryStateStore) LoadLastCheckpoint() (*checkpoint.Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.checkpoint, nil
+}
+
+func (s *memoryStateStore) SaveLastCheckpoint(cp *checkpoint.Checkpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checkpoint = cp
+
+	return nil
+}
+
+func (s *memoryStateStore) LoadLastMilestone() (*milestone.Milestone, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.milestone, nil
+}
+
+func (s *memoryStateStore) SaveLastMilestone(m *milestone.Milestone) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.milestone = m
+
+	return nil
+}
+
+// regressionGuard rejects latest checkpoints and milestones ending before the last
+// ones, kept in its store
+type regressionGuard struct {
+	mu    sync.Mutex
+	store StateStore
+}
+
+func (g *regressionGuard) checkCheckpoint(cp *checkpoint.Checkpoint) error {
+	if g == nil || cp.EndBlock == nil {
+		return nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	last, err := g.store.LoadLastCheckpoint()
+	if err != nil {
+		return fmt.Errorf("loading last checkpoint: %w", err)
+	}
+
+	if last != nil {
+		if err := checkRegression("checkpoint", cp.EndBlock, last.EndBlock); err != nil {
+			return err
+		}
+	}
+
+	if err := g.store.SaveLastCheckpoint(cp); err != nil {
+		return fmt.Errorf("saving last checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+func (g *regressionGuard) checkMilestone(m *milestone.Milestone) error {
+	if g == nil || m.EndBlock == nil {
+		return nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	last, err := g.store.LoadLastMilestone()
+	if err != nil {
+		return fmt.Errorf("loading last milestone: %w", err)
+	}
+
+	if last != nil {
+		if err := checkRegression("milestone", m.EndBlock, last.EndBlock); err != nil {
+			return err
+		}
+	}
+
+	if err := g.store.SaveLastMilestone(m); err != nil {
+		return fmt.Errorf("saving last milestone: %w", err)
+	}
+
+	return nil
+}
+
+func checkRegression(kind string, end, lastEnd *big.Int) error {
+	if lastEnd != nil && end.Cmp(lastEnd) < 0 {
+		return fmt.Errorf("%w: %s end block %v, last %v", ErrLatestRegressed, kind, end, lastEnd)
+	}
+
+	return nil
+}
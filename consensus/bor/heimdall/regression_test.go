@@ -0,0 +1,147 @@
+package heimdall
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/checkpoint"
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/milestone"
+)
+
+// encodedStateStore keeps the state encoded, like a store backed by a disk
+type encodedStateStore struct {
+	mu         sync.Mutex
+	checkpoint []byte
+	milestone  []byte
+}
+
+func (s *encodedStateStore) LoadLastCheckpoint() (*checkpoint.Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.checkpoint == nil {
+		return nil, nil
+	}
+
+	var cp checkpoint.Checkpoint
+
+	return &cp, json.Unmarshal(s.checkpoint, &cp)
+}
+
+func (s *encodedStateStore) SaveLastCheckpoint(cp *checkpoint.Checkpoint) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checkpoint, err = json.Marshal(cp)
+
+	return err
+}
+
+func (s *encodedStateStore) LoadLastMilestone() (*milestone.Milestone, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.milestone == nil {
+		return nil, nil
+	}
+
+	var m milestone.Milestone
+
+	return &m, json.Unmarshal(s.milestone, &m)
+}
+
+func (s *encodedStateStore) SaveLastMilestone(m *milestone.Milestone) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.milestone, err = json.Marshal(m)
+
+	return err
+}
+
+func TestRegressionGuardAcrossRestart(t *testing.T) {
+	t.Parallel()
+
+	var endBlock atomic.Int64
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"height":"0","result":{"start_block":1,"end_block":%d}}`, endBlock.Load())
+	}))
+	defer srv.Close()
+
+	store := &encodedStateStore{}
+
+	client := NewHeimdallClient(srv.URL, WithRetryBudget(1), WithRegressionGuard(store))
+
+	endBlock.Store(200)
+
+	_, err := client.FetchMilestone(context.Background())
+	require.NoError(t, err)
+
+	_, err = client.FetchCheckpoint(context.Background(), -1)
+	require.NoError(t, err)
+
+	client.Close()
+
+	// the restarted client loads the last state from the store
+	restarted := NewHeimdallClient(srv.URL, WithRetryBudget(1), WithRegressionGuard(store))
+	defer restarted.Close()
+
+	endBlock.Store(100)
+
+	_, err = restarted.FetchMilestone(context.Background())
+	require.ErrorIs(t, err, ErrLatestRegressed)
+
+	_, err = restarted.FetchCheckpoint(context.Background(), -1)
+	require.ErrorIs(t, err, ErrLatestRegressed)
+
+	// checkpoints fetched by number aren't guarded
+	_, err = restarted.FetchCheckpoint(context.Background(), 1)
+	require.NoError(t, err)
+
+	endBlock.Store(300)
+
+	m, err := restarted.FetchMilestone(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(300), m.EndBlock.Int64())
+
+	last, err := store.LoadLastMilestone()
+	require.NoError(t, err)
+	require.Equal(t, int64(300), last.EndBlock.Int64())
+}
+
+func TestRegressionGuardInMemory(t *testing.T) {
+	t.Parallel()
+
+	var endBlock atomic.Int64
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"height":"0","result":{"start_block":1,"end_block":%d}}`, endBlock.Load())
+	}))
+	defer srv.Close()
+
+	client := NewHeimdallClient(srv.URL, WithRetryBudget(1), WithRegressionGuard(nil))
+	defer client.Close()
+
+	endBlock.Store(200)
+
+	_, err := client.FetchMilestone(context.Background())
+	require.NoError(t, err)
+
+	// the same end block isn't a regression
+	_, err = client.FetchMilestone(context.Background())
+	require.NoError(t, err)
+
+	endBlock.Store(199)
+
+	_, err = client.FetchMilestone(context.Background())
+	require.ErrorIs(t, err, ErrLatestRegressed)
+}
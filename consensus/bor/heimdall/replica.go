@@ -0,0 +1,40 @@
+package heimdall
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// replicaKey marks the context of the requests to the replica heimdall, which get
+// circuit breakers of their own
+type replicaKey struct{}
+
+// fetchReadOnly fetches a non-critical read, like a count or a list, from the
+// replica heimdall if configured. The replica is tried once, and the primary one is
+// fetched as usual if it fails.
+func fetchReadOnly[T any](ctx context.Context, h *HeimdallClient, u *url.URL) (*T, error) {
+	if h.replicaURL == "" {
+		return fetchWithRetry[T](ctx, h, u)
+	}
+
+	replicaURL, err := makeURL(h.replicaURL, u.Path, u.RawQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := fetchWithRetryAttempts[T](context.WithValue(ctx, replicaKey{}, true), h, replicaURL, 1)
+	if err == nil {
+		return result, nil
+	}
+
+	if ctx.Err() != nil || errors.Is(err, ErrShutdownDetected) {
+		return nil, err
+	}
+
+	log.Debug("Falling back to primary heimdall", "path", u.Path, "error", err)
+
+	return fetchWithRetry[T](ctx, h, u)
+}
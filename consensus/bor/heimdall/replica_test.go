@@ -0,0 +1,107 @@
+package heimdall
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newCountServer starts a server serving the given checkpoint count and the latest
+// milestone, counting the requests to each path. It fails all requests if broken.
+func newCountServer(t *testing.T, count int64, broken bool) (*httptest.Server, map[string]*atomic.Int32) {
+	t.Helper()
+
+	requests := map[string]*atomic.Int32{
+		fetchCheckpointCount: new(atomic.Int32),
+		fetchMilestone:       new(atomic.Int32),
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if counter, ok := requests[r.URL.Path]; ok {
+			counter.Add(1)
+		}
+
+		if broken {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		switch r.URL.Path {
+		case fetchCheckpointCount:
+			fmt.Fprintf(w, `{"height":"0","result":{"result":%d}}`, count)
+		case fetchMilestone:
+			writeMilestone(w, 1)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, requests
+}
+
+func TestReplicaURL(t *testing.T) {
+	t.Parallel()
+
+	t.Run("count from replica, latest from primary", func(t *testing.T) {
+		t.Parallel()
+
+		primary, primaryRequests := newCountServer(t, 1, false)
+		replica, replicaRequests := newCountServer(t, 2, false)
+
+		client := NewHeimdallClient(primary.URL, WithReplicaURL(replica.URL))
+
+		count, err := client.FetchCheckpointCount(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(2), count)
+
+		_, err = client.FetchMilestone(context.Background())
+		require.NoError(t, err)
+
+		require.Equal(t, int32(1), replicaRequests[fetchCheckpointCount].Load())
+		require.Zero(t, replicaRequests[fetchMilestone].Load())
+		require.Zero(t, primaryRequests[fetchCheckpointCount].Load())
+		require.Equal(t, int32(1), primaryRequests[fetchMilestone].Load())
+	})
+
+	t.Run("fallback to primary", func(t *testing.T) {
+		t.Parallel()
+
+		primary, primaryRequests := newCountServer(t, 1, false)
+		replica, replicaRequests := newCountServer(t, 2, true)
+
+		client := NewHeimdallClient(primary.URL, WithReplicaURL(replica.URL))
+
+		count, err := client.FetchCheckpointCount(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(1), count)
+
+		require.Equal(t, int32(1), replicaRequests[fetchCheckpointCount].Load(), "expect no retry on the replica")
+		require.Equal(t, int32(1), primaryRequests[fetchCheckpointCount].Load())
+	})
+}
+
+func TestReplicaCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	primary, primaryRequests := newCountServer(t, 1, false)
+	replica, replicaRequests := newCountServer(t, 2, true)
+
+	client := NewHeimdallClient(primary.URL, WithReplicaURL(replica.URL), WithCircuitBreaker(2, time.Minute))
+
+	// the dead replica opens its own breaker, not the one of the primary
+	for i := 0; i < 4; i++ {
+		count, err := client.FetchCheckpointCount(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(1), count)
+	}
+
+	require.Equal(t, int32(2), replicaRequests[fetchCheckpointCount].Load(), "expect the replica breaker to open")
+	require.Equal(t, int32(4), primaryRequests[fetchCheckpointCount].Load())
+}
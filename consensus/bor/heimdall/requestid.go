@@ -0,0 +1,22 @@
+package heimdall
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// requestIDHeader carries the id of each request, which heimdall or a proxy in front
+// of it may log and echo back, so that the logs on both sides can be correlated
+const requestIDHeader = "X-Request-ID"
+
+// newRequestID returns a random request id
+func newRequestID() string {
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+
+	return hex.EncodeToString(id[:])
+}
@@ -0,0 +1,85 @@
+package heimdall
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// URLResolver returns the current url of heimdall, e.g. discovered from Consul or a
+// Kubernetes service. Only its scheme and host are used, the paths are the client's.
+type URLResolver func(ctx context.Context) (string, error)
+
+// urlResolver caches the url returned by a URLResolver for its ttl
+type urlResolver struct {
+	resolve URLResolver
+	ttl     time.Duration
+
+	mu       sync.Mutex
+	base     *url.URL
+	resolved time.Time
+}
+
+// get returns the cached url, resolving it again once expired. It keeps the last url
+// resolved if the resolver fails, and returns nil if none was resolved yet.
+func (r *urlResolver) get(ctx context.Context) *url.URL {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.base != nil && time.Since(r.resolved) < r.ttl {
+		return r.base
+	}
+
+	base, err := r.parse(ctx)
+	if err != nil {
+		log.Warn("Failed to resolve the heimdall url", "error", err)
+		return r.base
+	}
+
+	r.base = base
+	r.resolved = time.Now()
+
+	return base
+}
+
+func (r *urlResolver) parse(ctx context.Context) (*url.URL, error) {
+	raw, err := r.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("resolved url %q has no scheme or host", raw)
+	}
+
+	return base, nil
+}
+
+// resolveURL returns the url with the scheme and host of the resolved heimdall url,
+// if enabled with WithURLResolver, or the url itself. Only urls of the primary
+// heimdall are resolved, not the canary or replica ones.
+func (h *HeimdallClient) resolveURL(ctx context.Context, u *url.URL) (*url.URL, bool) {
+	if h.resolver == nil || u == nil || u.Host != h.authHost {
+		return u, false
+	}
+
+	base := h.resolver.get(ctx)
+	if base == nil {
+		return u, false
+	}
+
+	resolved := *u
+	resolved.Scheme = base.Scheme
+	resolved.Host = base.Host
+
+	return &resolved, true
+}
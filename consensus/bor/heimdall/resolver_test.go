@@ -0,0 +1,93 @@
+package heimdall
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLResolver(t *testing.T) {
+	t.Parallel()
+
+	newServer := func(n int64) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			writeMilestone(w, n)
+		}))
+	}
+
+	static, first, second := newServer(1), newServer(2), newServer(3)
+	defer static.Close()
+	defer first.Close()
+	defer second.Close()
+
+	var (
+		target   atomic.Value
+		resolves atomic.Int32
+	)
+
+	target.Store(first.URL)
+
+	resolver := func(context.Context) (string, error) {
+		resolves.Add(1)
+
+		if u := target.Load().(string); u != "" {
+			return u, nil
+		}
+
+		return "", errors.New("service not found")
+	}
+
+	client := NewHeimdallClient(static.URL, WithRetryBudget(1), WithURLResolver(resolver, time.Hour))
+	defer client.Close()
+
+	fetchEndBlock := func() int64 {
+		t.Helper()
+
+		m, err := client.FetchMilestone(context.Background())
+		require.NoError(t, err)
+
+		return m.EndBlock.Int64()
+	}
+
+	require.Equal(t, testMilestone(2).EndBlock.Int64(), fetchEndBlock())
+	require.Equal(t, testMilestone(2).EndBlock.Int64(), fetchEndBlock())
+	require.Equal(t, int32(1), resolves.Load(), "expect the resolved url to be cached for the ttl")
+
+	// the target moves once the cached url expired
+	client.resolver.ttl = 0
+	target.Store(second.URL)
+
+	require.Equal(t, testMilestone(3).EndBlock.Int64(), fetchEndBlock())
+
+	// the last url resolved is kept while the resolver fails
+	target.Store("")
+
+	require.Equal(t, testMilestone(3).EndBlock.Int64(), fetchEndBlock())
+}
+
+func TestURLResolverFallback(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeMilestone(w, 1)
+	}))
+	defer srv.Close()
+
+	resolver := func(context.Context) (string, error) {
+		return "", errors.New("service not found")
+	}
+
+	client := NewHeimdallClient(srv.URL, WithRetryBudget(1), WithURLResolver(resolver, time.Hour))
+	defer client.Close()
+
+	// the static url is used until one was resolved
+	m, err := client.FetchMilestone(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, testMilestone(1).EndBlock, m.EndBlock)
+}
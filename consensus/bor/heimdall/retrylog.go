@@ -0,0 +1,53 @@
+package heimdall
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// retryLogger logs the errors of the attempts of a request, logging an error only
+// once until it changes or the interval elapsed, if enabled with
+// WithRepeatedErrorSuppression
+type retryLogger struct {
+	path     string
+	interval time.Duration
+
+	last       string
+	loggedAt   time.Time
+	suppressed int
+}
+
+// log logs the error of the attempt unless it repeats the last one logged within the
+// interval. A repeated error logged after the interval is logged as still failing.
+func (l *retryLogger) log(attempt int, err error) {
+	signature := err.Error()
+
+	switch {
+	case signature != l.last:
+		log.Warn("an error while trying fetching from Heimdall", "path", l.path, "attempt", attempt, "error", err)
+	case time.Since(l.loggedAt) >= l.interval:
+		log.Warn("Heimdall request still failing", "path", l.path, "attempts", attempt, "suppressed", l.suppressed, "error", err)
+	default:
+		l.suppressed++
+		return
+	}
+
+	l.last = signature
+	l.loggedAt = time.Now()
+	l.suppressed = 0
+}
+
+// done logs a summary of the errors suppressed since the last one logged, once the
+// request succeeded or gave up with the given error
+func (l *retryLogger) done(attempt int, err error) {
+	if l.suppressed == 0 {
+		return
+	}
+
+	if err == nil {
+		log.Info("Heimdall request succeeded after repeated errors", "path", l.path, "attempts", attempt, "suppressed", l.suppressed, "error", l.last)
+	} else {
+		log.Warn("Heimdall request still failing after repeated errors", "path", l.path, "attempts", attempt, "suppressed", l.suppressed, "error", err)
+	}
+}
@@ -0,0 +1,340 @@
+package heimdall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/span"
+	"github.com/ethereum/go-ethereum/consensus/bor/valset"
+)
+
+var (
+	// ErrNoNextSpan is returned if heimdall has no next span to propose
+	ErrNoNextSpan = errors.New("no next span")
+
+	// ErrInvalidEpoch is returned if the epoch length is zero, or the next epoch
+	// would start beyond the last block number
+	ErrInvalidEpoch = errors.New("invalid epoch")
+)
+
+// defaultSpanFetchConcurrency is the default number of spans fetched concurrently
+const defaultSpanFetchConcurrency = 4
+
+// FetchLatestSpan fetches the latest committed span from heimdall
+func (h *HeimdallClient) FetchLatestSpan(ctx context.Context) (*span.HeimdallSpan, error) {
+	url, err := latestSpanURL(h.urlString)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = withRequestType(ctx, spanRequest)
+
+	response, err := fetchWithRetry[SpanResponse](ctx, h, url)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.Result, nil
+}
+
+// FetchNextSpan fetches the span heimdall would propose after the latest committed
+// one, which isn't committed yet. ErrNoNextSpan is returned if heimdall has none.
+func (h *HeimdallClient) FetchNextSpan(ctx context.Context) (*span.HeimdallSpan, error) {
+	latest, err := h.FetchLatestSpan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching latest span: %w", err)
+	}
+
+	url, err := nextSpanURL(h.urlString, latest.ID+1, latest.EndBlock+1, latest.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = withRequestType(ctx, spanRequest)
+
+	response, err := fetchWithRetry[SpanResponse](ctx, h, url)
+	if err != nil {
+		return nil, err
+	}
+
+	next := &response.Result
+	if next.ID == 0 || len(next.SelectedProducers) == 0 {
+		return nil, fmt.Errorf("%w: after span %d", ErrNoNextSpan, latest.ID)
+	}
+
+	return next, nil
+}
+
+// FetchValidatorSetAt fetches the validator set of the given span, with the voting
+// powers. Heimdall serves the historical sets with their spans.
+func (h *HeimdallClient) FetchValidatorSetAt(ctx context.Context, spanID uint64) (*valset.ValidatorSet, error) {
+	heimdallSpan, err := h.Span(ctx, spanID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching span %d: %w", spanID, err)
+	}
+
+	return &heimdallSpan.ValidatorSet, nil
+}
+
+// FetchGenesisValidators fetches the genesis validator set, the one of the zeroth
+// span. It never changes, so it is fetched once and then served from memory.
+func (h *HeimdallClient) FetchGenesisValidators(ctx context.Context) (*valset.ValidatorSet, error) {
+	h.genesisMu.Lock()
+	defer h.genesisMu.Unlock()
+
+	if h.genesisValidators == nil {
+		validators, err := h.FetchValidatorSetAt(ctx, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		h.genesisValidators = validators
+	}
+
+	// the callers may modify the set, like incrementing the proposer priorities
+	return h.genesisValidators.Copy(), nil
+}
+
+// SpanByBlock fetches the span governing the given block from heimdall, using the
+// span length configured with WithSpanLength
+func (h *HeimdallClient) SpanByBlock(ctx context.Context, blockNumber uint64) (*span.HeimdallSpan, error) {
+	spanID := span.IDAt(blockNumber, h.spanLength)
+
+	heimdallSpan, err := h.Span(ctx, spanID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching span %d: %w", spanID, err)
+	}
+
+	return heimdallSpan, nil
+}
+
+// SpanForStateSync fetches the span governing the block the state sync events are
+// processed at, like SpanByBlock. The consecutive events of a sync loop mostly fall
+// in the same span, so the last span fetched is reused while it covers the block.
+// The returned span is shared and must not be modified.
+func (h *HeimdallClient) SpanForStateSync(ctx context.Context, blockNumber uint64) (*span.HeimdallSpan, error) {
+	h.stateSyncSpanMu.Lock()
+	last := h.stateSyncSpan
+	h.stateSyncSpanMu.Unlock()
+
+	if last != nil && last.StartBlock <= blockNumber && blockNumber <= last.EndBlock {
+		return last, nil
+	}
+
+	heimdallSpan, err := h.SpanByBlock(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	h.stateSyncSpanMu.Lock()
+	h.stateSyncSpan = heimdallSpan
+	h.stateSyncSpanMu.Unlock()
+
+	return heimdallSpan, nil
+}
+
+// SpanForNextEpoch fetches the span governing the first block of the epoch following
+// the one of the current block, for epochs of the given length starting at genesis
+func (h *HeimdallClient) SpanForNextEpoch(ctx context.Context, currentBlock, epochLength uint64) (*span.HeimdallSpan, error) {
+	if epochLength == 0 {
+		return nil, fmt.Errorf("%w: zero epoch length", ErrInvalidEpoch)
+	}
+
+	start, ok := span.NextEpochStart(currentBlock, epochLength)
+	if !ok {
+		return nil, fmt.Errorf("%w: no epoch after block %d with length %d", ErrInvalidEpoch, currentBlock, epochLength)
+	}
+
+	return h.SpanByBlock(ctx, start)
+}
+
+// ProducerAt returns the expected producer of the given block, fetching the span
+// governing it from heimdall.
+func (h *HeimdallClient) ProducerAt(ctx context.Context, blockNumber, sprintLength uint64) (common.Address, error) {
+	heimdallSpan, err := h.SpanByBlock(ctx, blockNumber)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	return heimdallSpan.ProducerAt(blockNumber, sprintLength)
+}
+
+// SpansInRange fetches the spans with ids in [fromID, toID], ordered by id. Up to
+// the number of spans configured with WithSpanFetchConcurrency are fetched at once,
+// the first failure cancels the others.
+func (h *HeimdallClient) SpansInRange(ctx context.Context, fromID, toID uint64) ([]*span.HeimdallSpan, error) {
+	if toID < fromID {
+		return nil, nil
+	}
+
+	spans := make([]*span.HeimdallSpan, toID-fromID+1)
+
+	workers := h.spanFetchConcurrency
+	if workers > len(spans) {
+		workers = len(spans)
+	}
+
+	fetchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+
+	indices := make(chan int)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range indices {
+				id := fromID + uint64(i)
+
+				heimdallSpan, err := h.Span(fetchCtx, id)
+				if err != nil {
+					once.Do(func() {
+						firstErr = fmt.Errorf("fetching span %d: %w", id, err)
+						cancel()
+					})
+
+					continue
+				}
+
+				spans[i] = heimdallSpan
+			}
+		}()
+	}
+
+feed:
+	for i := range spans {
+		select {
+		case indices <- i:
+		case <-fetchCtx.Done():
+			break feed
+		}
+	}
+
+	close(indices)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return spans, nil
+}
+
+// SpanIterator walks the span list of heimdall page by page, prefetching the next
+// pages concurrently if configured, while yielding the spans in order
+type SpanIterator struct {
+	h        *HeimdallClient
+	ctx      context.Context
+	cancel   context.CancelFunc
+	limit    uint64
+	prefetch int
+
+	page    uint64              // next page to fetch
+	pending []chan spanListPage // pages in flight, in order
+	spans   []span.HeimdallSpan // spans of the current page not yielded yet
+	last    bool                // whether the last page was received
+	err     error
+}
+
+type spanListPage struct {
+	spans []span.HeimdallSpan
+	err   error
+}
+
+// NewSpanIterator returns an iterator over the span list, fetched by pages of the
+// given size. Up to prefetch pages after the current one are fetched concurrently,
+// so at most prefetch+1 pages are held in memory. Close must be called once done.
+func (h *HeimdallClient) NewSpanIterator(ctx context.Context, limit uint64, prefetch int) *SpanIterator {
+	if prefetch < 0 {
+		prefetch = 0
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	it := &SpanIterator{
+		h:        h,
+		ctx:      ctx,
+		cancel:   cancel,
+		limit:    limit,
+		prefetch: prefetch,
+		page:     1,
+	}
+	it.fill()
+
+	return it
+}
+
+// fill starts fetching pages until prefetch pages after the current one are in flight
+func (it *SpanIterator) fill() {
+	for !it.last && len(it.pending) < it.prefetch+1 {
+		page := it.page
+		it.page++
+
+		ch := make(chan spanListPage, 1)
+		it.pending = append(it.pending, ch)
+
+		go func() {
+			spans, err := it.h.FetchSpanList(it.ctx, page, it.limit)
+			ch <- spanListPage{spans: spans, err: err}
+		}()
+	}
+}
+
+// Next returns the next span, or io.EOF once all the spans were returned
+func (it *SpanIterator) Next() (*span.HeimdallSpan, error) {
+	for len(it.spans) == 0 {
+		if it.err != nil {
+			return nil, it.err
+		}
+
+		if len(it.pending) == 0 {
+			return nil, io.EOF
+		}
+
+		page := <-it.pending[0]
+		it.pending = it.pending[1:]
+
+		if page.err != nil {
+			it.err = page.err
+			it.Close()
+
+			return nil, it.err
+		}
+
+		// a short page is the last one, the pages prefetched after it are empty
+		if len(page.spans) == 0 || uint64(len(page.spans)) < it.limit {
+			it.last = true
+			it.pending = nil
+			it.Close()
+		}
+
+		it.spans = page.spans
+		it.fill()
+	}
+
+	next := it.spans[0]
+	it.spans = it.spans[1:]
+
+	return &next, nil
+}
+
+// Close stops fetching the pages in flight
+func (it *SpanIterator) Close() {
+	it.cancel()
+}
@@ -0,0 +1,128 @@
+package span
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/bor/valset"
+)
+
+// ErrProducerSelectionMismatch is returned if the selected producers of a span
+// aren't the ones selected from its validators with the seed
+var ErrProducerSelectionMismatch = errors.New("span producer selection mismatch")
+
+// VerifySpanProducers checks that the selected producers of the span are the ones
+// heimdall selects from the validators of the span with the given seed, the hash of
+// the block the span was proposed at, and the producer count of its bor params.
+func VerifySpanProducers(s *HeimdallSpan, seed common.Hash, producerCount uint64) error {
+	validators := make([]valset.Validator, 0, len(s.ValidatorSet.Validators))
+	for _, validator := range s.ValidatorSet.Validators {
+		validators = append(validators, *validator)
+	}
+
+	expected := SelectProducers(seed, validators, producerCount)
+	if !sameProducers(expected, s.SelectedProducers) {
+		return fmt.Errorf("%w: span %d, expected %v, got %v", ErrProducerSelectionMismatch, s.ID, producerIDs(expected), producerIDs(s.SelectedProducers))
+	}
+
+	return nil
+}
+
+// SelectProducers selects producerCount producers from the validators as heimdall
+// does, seeding the selection with the given hash. The producers are selected with
+// replacement, weighted by voting power, and sorted by address, and their voting
+// power is the number of times they were selected.
+//
+// If there are no more validators than producers to select, heimdall takes them all
+// as they are, in the same order and with their own voting power.
+func SelectProducers(seed common.Hash, validators []valset.Validator, producerCount uint64) []valset.Validator {
+	if uint64(len(validators)) <= producerCount {
+		return append([]valset.Validator(nil), validators...)
+	}
+
+	random := rand.New(rand.NewSource(int64(binary.BigEndian.Uint64(seed[:8])))) //nolint:gosec
+
+	// cumulative voting powers, e.g. [1, 2, 3] into [1, 3, 6]
+	weightedRanges := make([]uint64, len(validators))
+
+	var totalVotingPower uint64
+
+	for i, validator := range validators {
+		totalVotingPower += uint64(validator.VotingPower)
+		weightedRanges[i] = totalVotingPower
+	}
+
+	selections := make(map[uint64]int64)
+	producers := make([]valset.Validator, 0)
+
+	for i := uint64(0); i < producerCount; i++ {
+		// in [1, totalVotingPower] so that the first validator isn't favoured
+		target := randomRangeInclusive(random, 1, totalVotingPower)
+		validator := validators[sort.Search(len(weightedRanges), func(i int) bool { return weightedRanges[i] >= target })]
+
+		if selections[validator.ID] == 0 {
+			producers = append(producers, validator)
+		}
+
+		selections[validator.ID]++
+	}
+
+	for i := range producers {
+		producers[i].VotingPower = selections[producers[i].ID]
+	}
+
+	sort.Slice(producers, func(i, j int) bool {
+		return bytes.Compare(producers[i].Address[:], producers[j].Address[:]) < 0
+	})
+
+	return producers
+}
+
+// randomRangeInclusive returns an unbiased random number in [min, max], rejecting the
+// values beyond the largest multiple of the range length
+func randomRangeInclusive(random *rand.Rand, min, max uint64) uint64 {
+	if max <= min {
+		return max
+	}
+
+	rangeLength := max - min + 1
+	maxAllowedValue := math.MaxUint64 - math.MaxUint64%rangeLength - 1
+
+	value := random.Uint64()
+	for value >= maxAllowedValue {
+		value = random.Uint64()
+	}
+
+	return min + value%rangeLength
+}
+
+// sameProducers reports whether both lists hold the same validators with the same
+// voting power, in the same order
+func sameProducers(a, b []valset.Validator) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].ID != b[i].ID || a[i].Address != b[i].Address || a[i].VotingPower != b[i].VotingPower {
+			return false
+		}
+	}
+
+	return true
+}
+
+func producerIDs(producers []valset.Validator) []uint64 {
+	ids := make([]uint64, 0, len(producers))
+	for _, producer := range producers {
+		ids = append(ids, producer.ID)
+	}
+
+	return ids
+}
@@ -0,0 +1,200 @@
+package span
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/bor/valset"
+
+	"github.com/stretchr/testify/require"
+)
+
+// the seeds and validators of the selection tests of heimdall, the expected producers
+// being those heimdall selects from them
+const (
+	firstSeed  = "0x8f5bab218b6bb34476f51ca588e9f4553a3a7ce5e13a66c660a5283e97e9a85a"
+	secondSeed = "0xe09cc356df20c7a2dd38cb85b680a16ec29bd8b3e1ecc1b20f2e5603d5e7ee85"
+)
+
+func testValidators() []*valset.Validator {
+	return []*valset.Validator{
+		{ID: 3, Address: common.HexToAddress("0x1c4f0f054a0d6a1415382dc0fd83c6535188b220"), VotingPower: 10000, ProposerPriority: -40000},
+		{ID: 4, Address: common.HexToAddress("0x461295d3d9249215e758e939a150ab180950720b"), VotingPower: 10000, ProposerPriority: 10000},
+		{ID: 5, Address: common.HexToAddress("0x836fe3e3dd0a5f77d9d5b0f67e48048aaafcd5a0"), VotingPower: 10000, ProposerPriority: 10000},
+		{ID: 1, Address: common.HexToAddress("0x925a91f8003aaeabea6037103123b93c50b86ca3"), VotingPower: 10000, ProposerPriority: 10000},
+		{ID: 2, Address: common.HexToAddress("0xc787af4624cb3e80ee23ae7faac0f2acea2be34c"), VotingPower: 10000, ProposerPriority: 10000},
+	}
+}
+
+// weighted validators, listed out of address order
+func weightedValidators() []*valset.Validator {
+	return []*valset.Validator{
+		{ID: 1, Address: common.HexToAddress("0x3"), VotingPower: 100},
+		{ID: 2, Address: common.HexToAddress("0x1"), VotingPower: 200},
+		{ID: 3, Address: common.HexToAddress("0x2"), VotingPower: 300},
+		{ID: 4, Address: common.HexToAddress("0x4"), VotingPower: 400},
+	}
+}
+
+func TestVerifySpanProducers(t *testing.T) {
+	t.Parallel()
+
+	// selected is the producer with the given validator id, selected the given number
+	// of times
+	selected := func(validators []*valset.Validator) func(id uint64, selections int64) valset.Validator {
+		return func(id uint64, selections int64) valset.Validator {
+			for _, validator := range validators {
+				if validator.ID == id {
+					producer := *validator
+					producer.VotingPower = selections
+
+					return producer
+				}
+			}
+
+			t.Fatalf("no validator %d", id)
+
+			return valset.Validator{}
+		}
+	}
+
+	all := func(validators []*valset.Validator) []valset.Validator {
+		producers := make([]valset.Validator, 0, len(validators))
+		for _, validator := range validators {
+			producers = append(producers, *validator)
+		}
+
+		return producers
+	}
+
+	validators, weighted := testValidators(), weightedValidators()
+	producer, weightedProducer := selected(validators), selected(weighted)
+
+	tests := []struct {
+		name          string
+		validators    []*valset.Validator
+		seed          string
+		producerCount uint64
+		producers     []valset.Validator
+		err           error
+	}{
+		{
+			name:          "single producer",
+			validators:    validators,
+			seed:          firstSeed,
+			producerCount: 1,
+			producers:     []valset.Validator{producer(2, 1)},
+		},
+		{
+			name:          "producer selected twice",
+			validators:    validators,
+			seed:          firstSeed,
+			producerCount: 3,
+			producers:     []valset.Validator{producer(4, 2), producer(2, 1)},
+		},
+		{
+			name:          "four slots for three producers",
+			validators:    validators,
+			seed:          firstSeed,
+			producerCount: 4,
+			producers:     []valset.Validator{producer(4, 2), producer(1, 1), producer(2, 1)},
+		},
+		{
+			name:          "four producers",
+			validators:    validators,
+			seed:          secondSeed,
+			producerCount: 4,
+			producers:     []valset.Validator{producer(3, 1), producer(4, 1), producer(1, 1), producer(2, 1)},
+		},
+		{
+			name:          "all validators",
+			validators:    validators,
+			seed:          secondSeed,
+			producerCount: 5,
+			producers:     all(validators),
+		},
+		{
+			name:          "more producers than validators",
+			validators:    validators,
+			seed:          firstSeed,
+			producerCount: 10,
+			producers:     all(validators),
+		},
+		{
+			name:          "weighted producers",
+			validators:    weighted,
+			seed:          firstSeed,
+			producerCount: 3,
+			producers:     []valset.Validator{weightedProducer(2, 1), weightedProducer(3, 1), weightedProducer(1, 1)},
+		},
+		{
+			name:          "weighted producers of another seed",
+			validators:    weighted,
+			seed:          secondSeed,
+			producerCount: 3,
+			producers:     []valset.Validator{weightedProducer(2, 1), weightedProducer(1, 1), weightedProducer(4, 1)},
+		},
+		{
+			name:          "other seed",
+			validators:    validators,
+			seed:          secondSeed,
+			producerCount: 3,
+			producers:     []valset.Validator{producer(4, 2), producer(2, 1)},
+			err:           ErrProducerSelectionMismatch,
+		},
+		{
+			name:          "all validators instead of a selection",
+			validators:    validators,
+			seed:          firstSeed,
+			producerCount: 4,
+			producers:     all(validators),
+			err:           ErrProducerSelectionMismatch,
+		},
+		{
+			name:          "selection instead of all validators",
+			validators:    weighted,
+			seed:          firstSeed,
+			producerCount: 4,
+			producers:     []valset.Validator{weightedProducer(2, 1), weightedProducer(3, 2), weightedProducer(1, 1)},
+			err:           ErrProducerSelectionMismatch,
+		},
+		{
+			name:          "unsorted producers",
+			validators:    validators,
+			seed:          firstSeed,
+			producerCount: 3,
+			producers:     []valset.Validator{producer(2, 1), producer(4, 2)},
+			err:           ErrProducerSelectionMismatch,
+		},
+		{
+			name:          "selections as voting power",
+			validators:    validators,
+			seed:          firstSeed,
+			producerCount: 3,
+			producers:     []valset.Validator{producer(4, 10000), producer(2, 10000)},
+			err:           ErrProducerSelectionMismatch,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := &HeimdallSpan{
+				Span:              Span{ID: 1, StartBlock: 256, EndBlock: 6655},
+				ValidatorSet:      valset.ValidatorSet{Validators: test.validators},
+				SelectedProducers: test.producers,
+			}
+
+			err := VerifySpanProducers(s, common.HexToHash(test.seed), test.producerCount)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
@@ -1,9 +1,30 @@
 package span
 
 import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/bor/valset"
 )
 
+var (
+	// ErrBlockNotInSpan is returned if a block isn't covered by the span
+	ErrBlockNotInSpan = errors.New("block is not in span")
+
+	// ErrNoProducers is returned if the span has no selected producers
+	ErrNoProducers = errors.New("span has no selected producers")
+)
+
+const (
+	// ZerothSpanEnd is the last block of the first span, which starts at genesis
+	ZerothSpanEnd = 255
+
+	// DefaultSpanLength is the number of blocks covered by each span after the first
+	DefaultSpanLength = 6400
+)
+
 // Span Bor represents a current bor span
 type Span struct {
 	ID         uint64 `json:"span_id" yaml:"span_id"`
@@ -18,3 +39,55 @@ type HeimdallSpan struct {
 	SelectedProducers []valset.Validator  `json:"selected_producers" yaml:"selected_producers"`
 	ChainID           string              `json:"bor_chain_id" yaml:"bor_chain_id"`
 }
+
+// IDAt returns the id of the span containing the given block, for spans of the
+// given length following the zeroth span
+func IDAt(number, spanLength uint64) uint64 {
+	if number <= ZerothSpanEnd {
+		return 0
+	}
+
+	return (number-ZerothSpanEnd-1)/spanLength + 1
+}
+
+// NextEpochStart returns the first block of the epoch following the one of the given
+// block, for epochs of the given positive length starting at genesis. It reports
+// false if the next epoch would start beyond the largest block number.
+func NextEpochStart(number, epochLength uint64) (uint64, bool) {
+	epoch := number / epochLength
+	if epoch >= math.MaxUint64/epochLength {
+		return 0, false
+	}
+
+	return (epoch + 1) * epochLength, true
+}
+
+// ProducerAt returns the expected producer of the given block of the span. The
+// proposer is taken from the selected producers at the start of the span and
+// rotates once per sprint, following their proposer priorities.
+func (s *HeimdallSpan) ProducerAt(number, sprintLength uint64) (common.Address, error) {
+	if number < s.StartBlock || number > s.EndBlock {
+		return common.Address{}, fmt.Errorf("%w: block %d, span %d [%d, %d]", ErrBlockNotInSpan, number, s.ID, s.StartBlock, s.EndBlock)
+	}
+
+	if sprintLength == 0 {
+		return common.Address{}, errors.New("sprint length must be positive")
+	}
+
+	if len(s.SelectedProducers) == 0 {
+		return common.Address{}, fmt.Errorf("%w: span %d", ErrNoProducers, s.ID)
+	}
+
+	producers := make([]*valset.Validator, 0, len(s.SelectedProducers))
+	for i := range s.SelectedProducers {
+		producers = append(producers, s.SelectedProducers[i].Copy())
+	}
+
+	vals := valset.NewValidatorSet(producers)
+
+	if sprint := (number - s.StartBlock) / sprintLength; sprint > 0 {
+		vals.IncrementProposerPriority(int(sprint))
+	}
+
+	return vals.GetProposer().Address, nil
+}
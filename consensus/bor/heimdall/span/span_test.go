@@ -0,0 +1,48 @@
+package span
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIDAt(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		number uint64
+		id     uint64
+	}{
+		{number: 0, id: 0},
+		{number: ZerothSpanEnd, id: 0},
+		{number: ZerothSpanEnd + 1, id: 1},
+		{number: ZerothSpanEnd + DefaultSpanLength, id: 1},
+		{number: ZerothSpanEnd + DefaultSpanLength + 1, id: 2},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.id, IDAt(test.number, DefaultSpanLength), "block %d", test.number)
+	}
+}
+
+func TestNextEpochStart(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		number, epochLength, start uint64
+		ok                         bool
+	}{
+		{number: 0, epochLength: 64, start: 64, ok: true},
+		{number: 63, epochLength: 64, start: 64, ok: true},
+		{number: 64, epochLength: 64, start: 128, ok: true},
+		{number: math.MaxUint64 - 1, epochLength: 2, ok: false},
+		{number: math.MaxUint64, epochLength: 1, ok: false},
+	}
+
+	for _, test := range tests {
+		start, ok := NextEpochStart(test.number, test.epochLength)
+		require.Equal(t, test.ok, ok, "block %d", test.number)
+		require.Equal(t, test.start, start, "block %d", test.number)
+	}
+}
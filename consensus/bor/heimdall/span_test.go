@@ -0,0 +1,471 @@
+package heimdall
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/span"
+	"github.com/ethereum/go-ethereum/consensus/bor/valset"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testSpan returns the span with the given id, of the given length, with three
+// selected producers of equal power
+func testSpan(id, length uint64) span.HeimdallSpan {
+	start := uint64(0)
+	end := uint64(span.ZerothSpanEnd)
+
+	if id > 0 {
+		start = span.ZerothSpanEnd + 1 + (id-1)*length
+		end = start + length - 1
+	}
+
+	producers := []valset.Validator{
+		{ID: 1, Address: common.HexToAddress("0x1"), VotingPower: 10},
+		{ID: 2, Address: common.HexToAddress("0x2"), VotingPower: 10},
+		{ID: 3, Address: common.HexToAddress("0x3"), VotingPower: 10},
+	}
+
+	return span.HeimdallSpan{
+		Span:              span.Span{ID: id, StartBlock: start, EndBlock: end},
+		SelectedProducers: producers,
+		ChainID:           "15001",
+	}
+}
+
+// newSpanServer starts a server serving the spans of the given length
+func newSpanServer(t *testing.T, length uint64) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var id uint64
+		if _, err := fmt.Sscanf(r.URL.Path, "/"+fetchSpanFormat, &id); err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(SpanResponse{Height: "0", Result: testSpan(id, length)})
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestProducerAt(t *testing.T) {
+	t.Parallel()
+
+	const sprint = 16
+
+	srv := newSpanServer(t, span.DefaultSpanLength)
+	client := NewHeimdallClient(srv.URL)
+
+	start := uint64(span.ZerothSpanEnd + 1)
+
+	producerAt := func(number uint64) common.Address {
+		producer, err := client.ProducerAt(context.Background(), number, sprint)
+		require.NoError(t, err)
+
+		return producer
+	}
+
+	// The producer stays the same within a sprint
+	first := producerAt(start)
+	require.Equal(t, first, producerAt(start+sprint-1))
+
+	// and rotates across all the producers over the following sprints
+	seen := map[common.Address]struct{}{first: {}}
+
+	for i := uint64(1); i < 3; i++ {
+		producer := producerAt(start + i*sprint)
+		require.NotEqual(t, producerAt(start+(i-1)*sprint), producer, "expect the producer to change with the sprint")
+
+		seen[producer] = struct{}{}
+	}
+
+	require.Len(t, seen, 3, "expect every producer to get a turn")
+	require.Equal(t, first, producerAt(start+3*sprint), "expect the rotation to wrap around")
+
+	// The zeroth span governs the first blocks
+	_, err := client.ProducerAt(context.Background(), span.ZerothSpanEnd, sprint)
+	require.NoError(t, err)
+}
+
+func TestSpanProducerAtValidation(t *testing.T) {
+	t.Parallel()
+
+	s := testSpan(1, span.DefaultSpanLength)
+
+	_, err := s.ProducerAt(s.EndBlock+1, 16)
+	require.ErrorIs(t, err, span.ErrBlockNotInSpan)
+
+	_, err = s.ProducerAt(s.StartBlock, 0)
+	require.Error(t, err)
+
+	s.SelectedProducers = nil
+
+	_, err = s.ProducerAt(s.StartBlock, 16)
+	require.ErrorIs(t, err, span.ErrNoProducers)
+}
+
+func TestFetchSpanList(t *testing.T) {
+	t.Parallel()
+
+	const total = 3
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var page, limit uint64
+		if r.URL.Path != "/"+fetchSpanListPath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if _, err := fmt.Sscanf(r.URL.RawQuery, fetchSpanListFormat, &page, &limit); err != nil || page == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		spans := []span.HeimdallSpan{}
+		for id := (page - 1) * limit; id < page*limit && id < total; id++ {
+			spans = append(spans, testSpan(id, span.DefaultSpanLength))
+		}
+
+		_ = json.NewEncoder(w).Encode(SpanListResponse{Height: "0", Result: spans})
+	}))
+	t.Cleanup(srv.Close)
+
+	client := NewHeimdallClient(srv.URL)
+
+	first, err := client.FetchSpanList(context.Background(), 1, 2)
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	require.Equal(t, uint64(0), first[0].ID)
+	require.Equal(t, uint64(1), first[1].ID)
+
+	second, err := client.FetchSpanList(context.Background(), 2, 2)
+	require.NoError(t, err)
+	require.Len(t, second, 1)
+	require.Equal(t, testSpan(2, span.DefaultSpanLength), second[0])
+}
+
+func TestSpanByBlockSpanLength(t *testing.T) {
+	t.Parallel()
+
+	const length = 1024
+
+	srv := newSpanServer(t, length)
+
+	tests := []struct {
+		number uint64
+		id     uint64
+	}{
+		{number: 0, id: 0},
+		{number: span.ZerothSpanEnd, id: 0},
+		{number: span.ZerothSpanEnd + 1, id: 1},
+		{number: span.ZerothSpanEnd + length, id: 1},
+		{number: span.ZerothSpanEnd + length + 1, id: 2},
+		{number: span.ZerothSpanEnd + 5*length, id: 5},
+	}
+
+	client := NewHeimdallClient(srv.URL, WithSpanLength(length))
+
+	for _, test := range tests {
+		heimdallSpan, err := client.SpanByBlock(context.Background(), test.number)
+		require.NoError(t, err)
+		require.Equal(t, test.id, heimdallSpan.ID, "block %d", test.number)
+		require.True(t, heimdallSpan.StartBlock <= test.number && test.number <= heimdallSpan.EndBlock)
+	}
+
+	// a zero length keeps the default
+	require.Equal(t, uint64(span.DefaultSpanLength), NewHeimdallClient(srv.URL, WithSpanLength(0)).spanLength)
+}
+
+func TestSpanForStateSync(t *testing.T) {
+	t.Parallel()
+
+	const length = 1024
+
+	client := NewHeimdallClient(newSpanServer(t, length).URL, WithSpanLength(length))
+
+	tests := []struct {
+		number   uint64
+		id       uint64
+		requests int
+	}{
+		{number: span.ZerothSpanEnd + 1, id: 1, requests: 1},
+		{number: span.ZerothSpanEnd + length, id: 1, requests: 0},
+		{number: span.ZerothSpanEnd + length + 1, id: 2, requests: 1},
+		{number: span.ZerothSpanEnd + length + 2, id: 2, requests: 0},
+		{number: 0, id: 0, requests: 1},
+	}
+
+	for _, test := range tests {
+		var meta FetchMeta
+
+		heimdallSpan, err := client.SpanForStateSync(WithFetchMeta(context.Background(), &meta), test.number)
+		require.NoError(t, err)
+		require.Equal(t, test.id, heimdallSpan.ID, "block %d", test.number)
+		require.Equal(t, test.requests, meta.Requests(), "block %d", test.number)
+	}
+}
+
+func TestSpanForNextEpoch(t *testing.T) {
+	t.Parallel()
+
+	const length = 1024
+
+	client := NewHeimdallClient(newSpanServer(t, length).URL, WithSpanLength(length))
+
+	tests := []struct {
+		current     uint64
+		epochLength uint64
+		id          uint64
+	}{
+		{current: 100, epochLength: 512, id: 1},
+		{current: 200, epochLength: 256, id: 1}, // the next epoch starts with span 1
+		{current: 1000, epochLength: 512, id: 1},
+		{current: 1100, epochLength: 512, id: 2}, // the next epoch is in the next span
+		{current: 1279, epochLength: 1, id: 2},
+	}
+
+	for _, test := range tests {
+		heimdallSpan, err := client.SpanForNextEpoch(context.Background(), test.current, test.epochLength)
+		require.NoError(t, err)
+		require.Equal(t, test.id, heimdallSpan.ID, "block %d, epoch length %d", test.current, test.epochLength)
+	}
+
+	_, err := client.SpanForNextEpoch(context.Background(), 100, 0)
+	require.ErrorIs(t, err, ErrInvalidEpoch)
+
+	_, err = client.SpanForNextEpoch(context.Background(), math.MaxUint64-1, 1<<32)
+	require.ErrorIs(t, err, ErrInvalidEpoch)
+}
+
+func TestFetchNextSpan(t *testing.T) {
+	t.Parallel()
+
+	newServer := func(next func(id uint64) SpanResponse) *httptest.Server {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/" + fetchLatestSpan:
+				_ = json.NewEncoder(w).Encode(SpanResponse{Height: "0", Result: testSpan(4, span.DefaultSpanLength)})
+			case "/" + fetchNextSpanPath:
+				var (
+					id, start uint64
+					chainID   string
+				)
+
+				if _, err := fmt.Sscanf(r.URL.RawQuery, "span_id=%d&start_block=%d&chain_id=%s", &id, &start, &chainID); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+
+				if start != testSpan(4, span.DefaultSpanLength).EndBlock+1 || chainID != "15001" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+
+				_ = json.NewEncoder(w).Encode(next(id))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		t.Cleanup(srv.Close)
+
+		return srv
+	}
+
+	srv := newServer(func(id uint64) SpanResponse {
+		return SpanResponse{Height: "0", Result: testSpan(id, span.DefaultSpanLength)}
+	})
+
+	next, err := NewHeimdallClient(srv.URL).FetchNextSpan(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, testSpan(5, span.DefaultSpanLength), *next)
+
+	// an empty span means there is nothing to propose
+	srv = newServer(func(uint64) SpanResponse {
+		return SpanResponse{Height: "0"}
+	})
+
+	_, err = NewHeimdallClient(srv.URL).FetchNextSpan(context.Background())
+	require.ErrorIs(t, err, ErrNoNextSpan)
+}
+
+func TestSpansInRangeConcurrency(t *testing.T) {
+	t.Parallel()
+
+	const concurrency = 3
+
+	var inFlight, maxInFlight atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		for {
+			max := maxInFlight.Load()
+			if n <= max || maxInFlight.CompareAndSwap(max, n) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+
+		var id uint64
+		if _, err := fmt.Sscanf(r.URL.Path, "/"+fetchSpanFormat, &id); err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(SpanResponse{Height: "0", Result: testSpan(id, span.DefaultSpanLength)})
+	}))
+	t.Cleanup(srv.Close)
+
+	client := NewHeimdallClient(srv.URL, WithSpanFetchConcurrency(concurrency))
+
+	spans, err := client.SpansInRange(context.Background(), 2, 13)
+	require.NoError(t, err)
+	require.Len(t, spans, 12)
+
+	for i, heimdallSpan := range spans {
+		require.Equal(t, uint64(i+2), heimdallSpan.ID)
+	}
+
+	require.Equal(t, int32(concurrency), maxInFlight.Load())
+
+	// a non-positive concurrency keeps the default
+	require.Equal(t, defaultSpanFetchConcurrency, NewHeimdallClient(srv.URL, WithSpanFetchConcurrency(0)).spanFetchConcurrency)
+}
+
+func TestSpanIteratorPrefetch(t *testing.T) {
+	t.Parallel()
+
+	const (
+		total    = 23
+		limit    = 5
+		prefetch = 2
+	)
+
+	var inFlight, maxInFlight atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		for {
+			max := maxInFlight.Load()
+			if n <= max || maxInFlight.CompareAndSwap(max, n) {
+				break
+			}
+		}
+
+		var page, limit uint64
+		if _, err := fmt.Sscanf(r.URL.RawQuery, fetchSpanListFormat, &page, &limit); err != nil || page == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		// the later pages are served faster, so that they arrive out of order
+		time.Sleep(time.Duration(10-page%10) * 5 * time.Millisecond)
+
+		spans := []span.HeimdallSpan{}
+		for id := (page - 1) * limit; id < page*limit && id < total; id++ {
+			spans = append(spans, testSpan(id, span.DefaultSpanLength))
+		}
+
+		_ = json.NewEncoder(w).Encode(SpanListResponse{Height: "0", Result: spans})
+	}))
+	t.Cleanup(srv.Close)
+
+	it := NewHeimdallClient(srv.URL).NewSpanIterator(context.Background(), limit, prefetch)
+	defer it.Close()
+
+	for id := uint64(0); id < total; id++ {
+		next, err := it.Next()
+		require.NoError(t, err)
+		require.Equal(t, id, next.ID)
+	}
+
+	_, err := it.Next()
+	require.ErrorIs(t, err, io.EOF)
+
+	require.LessOrEqual(t, maxInFlight.Load(), int32(prefetch+1))
+	require.Greater(t, maxInFlight.Load(), int32(1), "expect the pages to be prefetched")
+}
+
+func TestFetchValidatorSetAt(t *testing.T) {
+	t.Parallel()
+
+	validators := []*valset.Validator{
+		{ID: 1, Address: common.HexToAddress("0x1"), VotingPower: 10},
+		{ID: 2, Address: common.HexToAddress("0x2"), VotingPower: 25},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/"+fmt.Sprintf(fetchSpanFormat, 7) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		heimdallSpan := testSpan(7, span.DefaultSpanLength)
+		heimdallSpan.ValidatorSet = valset.ValidatorSet{Validators: validators, Proposer: validators[1]}
+
+		_ = json.NewEncoder(w).Encode(SpanResponse{Height: "0", Result: heimdallSpan})
+	}))
+	t.Cleanup(srv.Close)
+
+	set, err := NewHeimdallClient(srv.URL).FetchValidatorSetAt(context.Background(), 7)
+	require.NoError(t, err)
+	require.Equal(t, validators, set.Validators)
+	require.Equal(t, validators[1], set.Proposer)
+	require.Equal(t, int64(35), set.TotalVotingPower())
+}
+
+func TestFetchGenesisValidators(t *testing.T) {
+	t.Parallel()
+
+	validators := []*valset.Validator{
+		{ID: 1, Address: common.HexToAddress("0x1"), VotingPower: 10},
+	}
+
+	var requests atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		if r.URL.Path != "/"+fmt.Sprintf(fetchSpanFormat, 0) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		heimdallSpan := testSpan(0, span.DefaultSpanLength)
+		heimdallSpan.ValidatorSet = valset.ValidatorSet{Validators: validators, Proposer: validators[0]}
+
+		_ = json.NewEncoder(w).Encode(SpanResponse{Height: "0", Result: heimdallSpan})
+	}))
+	t.Cleanup(srv.Close)
+
+	client := NewHeimdallClient(srv.URL)
+
+	first, err := client.FetchGenesisValidators(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, validators, first.Validators)
+
+	// modifying the returned set doesn't affect the cached one
+	first.Validators[0].VotingPower = 99
+
+	second, err := client.FetchGenesisValidators(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(10), second.Validators[0].VotingPower)
+	require.Equal(t, int32(1), requests.Load(), "expect the second call served from memory")
+}
@@ -0,0 +1,93 @@
+package heimdall
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// staleCountAttempts bounds the attempts of a count fetch while a last count can be
+// served instead, which would never be with unbounded retries
+const staleCountAttempts = 3
+
+// maxAttemptsKey bounds the attempts of the fetches made with the context
+type maxAttemptsKey struct{}
+
+// staleCounts keeps the last successfully fetched counts, served when fetching a
+// fresh one fails
+type staleCounts struct {
+	maxAge time.Duration
+
+	mu     sync.Mutex
+	counts map[requestType]staleCount
+}
+
+type staleCount struct {
+	count     int64
+	fetchedAt time.Time
+}
+
+func newStaleCounts(maxAge time.Duration) *staleCounts {
+	return &staleCounts{
+		maxAge: maxAge,
+		counts: make(map[requestType]staleCount),
+	}
+}
+
+func (c *staleCounts) put(key requestType, count int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.counts[key] = staleCount{count: count, fetchedAt: time.Now()}
+}
+
+// get returns the last count of the key, unless older than the max age
+func (c *staleCounts) get(key requestType) (staleCount, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	last, ok := c.counts[key]
+	if !ok || time.Since(last.fetchedAt) > c.maxAge {
+		return staleCount{}, false
+	}
+
+	return last, true
+}
+
+// fetchCount fetches a count, falling back to the last one if enabled with
+// WithStaleCountFallback, after at most staleCountAttempts attempts. A context
+// cancellation or a shutdown isn't covered.
+func (h *HeimdallClient) fetchCount(ctx context.Context, key requestType, fetch func(ctx context.Context) (int64, error)) (int64, error) {
+	if h.lastCounts == nil {
+		return h.coalesceCount(ctx, key, fetch)
+	}
+
+	if _, ok := h.lastCounts.get(key); ok {
+		ctx = context.WithValue(ctx, maxAttemptsKey{}, staleCountAttempts)
+	}
+
+	count, err := h.coalesceCount(ctx, key, fetch)
+
+	if err == nil {
+		h.lastCounts.put(key, count)
+		return count, nil
+	}
+
+	if ctx.Err() != nil || errors.Is(err, ErrShutdownDetected) {
+		return 0, err
+	}
+
+	last, ok := h.lastCounts.get(key)
+	if !ok {
+		return 0, err
+	}
+
+	log.Warn("Fetching count from heimdall failed, using the last one", "type", key, "count", last.count, "age", time.Since(last.fetchedAt), "err", err)
+
+	markStale(ctx)
+
+	return last.count, nil
+}
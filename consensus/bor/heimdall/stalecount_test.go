@@ -0,0 +1,98 @@
+package heimdall
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaleCountFallback(t *testing.T) {
+	t.Parallel()
+
+	var failing atomic.Bool
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		switch r.URL.Path {
+		case fetchCheckpointCount:
+			_, _ = w.Write([]byte(`{"height":"0","result":{"result":5}}`))
+		case fetchMilestoneCount:
+			_, _ = w.Write([]byte(`{"height":"0","result":{"count":7}}`))
+		}
+	}))
+	defer srv.Close()
+
+	client := NewHeimdallClient(srv.URL, WithRetryBudget(1), WithStaleCountFallback(time.Minute))
+
+	var fresh FetchMeta
+
+	count, err := client.FetchCheckpointCount(WithFetchMeta(context.Background(), &fresh))
+	require.NoError(t, err)
+	require.Equal(t, int64(5), count)
+	require.False(t, fresh.Stale())
+
+	failing.Store(true)
+
+	var stale FetchMeta
+
+	count, err = client.FetchCheckpointCount(WithFetchMeta(context.Background(), &stale))
+	require.NoError(t, err)
+	require.Equal(t, int64(5), count)
+	require.True(t, stale.Stale())
+
+	// no count was fetched before for milestones
+	_, err = client.FetchMilestoneCount(context.Background())
+	require.ErrorIs(t, err, ErrNotSuccessfulResponse)
+
+	// the last count isn't served once older than the max age
+	client.lastCounts.maxAge = 0
+
+	_, err = client.FetchCheckpointCount(context.Background())
+	require.ErrorIs(t, err, ErrNotSuccessfulResponse)
+}
+
+func TestStaleCountFallbackWithoutRetryBudget(t *testing.T) {
+	t.Parallel()
+
+	var (
+		failing  atomic.Bool
+		requests atomic.Int32
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"height":"0","result":{"result":5}}`))
+	}))
+	defer srv.Close()
+
+	// the retries are unbounded by default
+	client := NewHeimdallClient(srv.URL, WithStaleCountFallback(time.Minute))
+	client.retryInterval = time.Millisecond
+
+	count, err := client.FetchCheckpointCount(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(5), count)
+
+	failing.Store(true)
+	requests.Store(0)
+
+	count, err = client.FetchCheckpointCount(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(5), count)
+	require.Equal(t, int32(staleCountAttempts), requests.Load(), "expect the attempts to be bounded while a last count can be served")
+}
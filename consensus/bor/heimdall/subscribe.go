@@ -0,0 +1,144 @@
+package heimdall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/milestone"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	fetchMilestoneSubscribeFormat = "after=%d"
+	fetchMilestoneSubscribePath   = "/milestone/subscribe"
+
+	// milestoneLongPollTimeout is the timeout of a single long poll request. Heimdall
+	// replies with 204 if no new milestone arrived in the meantime.
+	milestoneLongPollTimeout = time.Minute
+
+	maxSubscribeBackoff = time.Minute
+)
+
+// SubscribeMilestones emits the milestones as heimdall produces them, until the
+// context is done or the client is closed, after which the channel is closed. It
+// keeps a long poll request open to heimdall for the milestone following the last
+// one emitted, and reconnects with backoff on failures. If heimdall doesn't expose
+// the long poll endpoint, it falls back to polling the latest milestone.
+func (h *HeimdallClient) SubscribeMilestones(ctx context.Context) (<-chan *milestone.Milestone, error) {
+	if _, err := milestoneSubscribeURL(h.urlString, 0); err != nil {
+		return nil, err
+	}
+
+	ch := make(chan *milestone.Milestone)
+
+	go h.subscribeMilestones(ctx, ch)
+
+	return ch, nil
+}
+
+func (h *HeimdallClient) subscribeMilestones(ctx context.Context, ch chan<- *milestone.Milestone) {
+	defer close(ch)
+
+	var (
+		after    uint64
+		longPoll = true
+		backoff  = h.retryInterval
+	)
+
+	ctx = withRequestType(ctx, milestoneRequest)
+
+	for {
+		var (
+			m   *milestone.Milestone
+			err error
+		)
+
+		if longPoll {
+			m, err = h.pollMilestone(ctx, after)
+
+			var statusErr *StatusError
+			if errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusNotFound || statusErr.StatusCode == http.StatusNotImplemented) {
+				log.Info("Heimdall doesn't support milestone subscriptions, polling the latest milestone instead")
+
+				longPoll = false
+
+				continue
+			}
+		} else {
+			m, err = h.FetchMilestone(ctx)
+		}
+
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, ErrShutdownDetected) {
+				return
+			}
+
+			log.Warn("Failed to fetch the next milestone from Heimdall, reconnecting", "in", backoff, "error", err)
+
+			if h.wait(ctx, backoff) != nil {
+				return
+			}
+
+			backoff *= 2
+			if backoff > maxSubscribeBackoff {
+				backoff = maxSubscribeBackoff
+			}
+
+			continue
+		}
+
+		backoff = h.retryInterval
+
+		if m == nil || m.EndBlock == nil || m.EndBlock.Uint64() <= after {
+			// No new milestone yet. A long poll timing out immediately waits for the
+			// next one, but a heimdall ignoring after replies with the last milestone
+			// right away.
+			if (!longPoll || m != nil) && h.wait(ctx, h.retryInterval) != nil {
+				return
+			}
+
+			continue
+		}
+
+		after = m.EndBlock.Uint64()
+
+		select {
+		case ch <- m:
+		case <-ctx.Done():
+			return
+		case <-h.closeCh:
+			return
+		}
+	}
+}
+
+// pollMilestone waits for heimdall to serve the milestone ending after the given
+// block. It returns a nil milestone if none arrived before the long poll timed out.
+func (h *HeimdallClient) pollMilestone(ctx context.Context, after uint64) (*milestone.Milestone, error) {
+	url, err := milestoneSubscribeURL(h.urlString, after)
+	if err != nil {
+		return nil, err
+	}
+
+	request := h.newRequest(ctx, url, 1)
+	request.timeout = milestoneLongPollTimeout
+
+	response, err := Fetch[milestone.MilestoneResponse](ctx, request)
+	if errors.Is(err, ErrNoResponse) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.Result, nil
+}
+
+func milestoneSubscribeURL(urlString string, after uint64) (*url.URL, error) {
+	return makeURL(urlString, fetchMilestoneSubscribePath, fmt.Sprintf(fetchMilestoneSubscribeFormat, after))
+}
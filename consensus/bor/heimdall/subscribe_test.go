@@ -0,0 +1,145 @@
+package heimdall
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/milestone"
+
+	"github.com/stretchr/testify/require"
+)
+
+func receiveMilestone(t *testing.T, ch <-chan *milestone.Milestone) *milestone.Milestone {
+	t.Helper()
+
+	select {
+	case m, ok := <-ch:
+		require.True(t, ok, "expect the subscription to be open")
+		return m
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a milestone")
+	}
+
+	return nil
+}
+
+func TestSubscribeMilestonesLongPoll(t *testing.T) {
+	t.Parallel()
+
+	// Serve the milestone following the one given by the client, holding the request
+	// until the client gives up once the first two milestones were served
+	mux := http.NewServeMux()
+	mux.HandleFunc(fetchMilestoneSubscribePath, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("after") {
+		case "0":
+			writeMilestone(w, 1)
+		case "16":
+			time.Sleep(20 * time.Millisecond)
+			writeMilestone(w, 2)
+		default:
+			<-r.Context().Done()
+		}
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	client := NewHeimdallClient(srv.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ch, err := client.SubscribeMilestones(ctx)
+	require.NoError(t, err)
+
+	require.Equal(t, big.NewInt(16), receiveMilestone(t, ch).EndBlock)
+	require.Equal(t, big.NewInt(32), receiveMilestone(t, ch).EndBlock)
+
+	cancel()
+
+	_, ok := <-ch
+	require.False(t, ok, "expect the subscription to be closed with the context")
+}
+
+func TestPollMilestoneHeldLong(t *testing.T) {
+	t.Parallel()
+
+	// heimdall holds the long poll past the default timeout until a milestone arrives
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(apiHeimdallTimeout + 2*time.Second)
+		writeMilestone(w, 1)
+	}))
+	t.Cleanup(srv.Close)
+
+	m, err := NewHeimdallClient(srv.URL).pollMilestone(context.Background(), 0)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(16), m.EndBlock)
+}
+
+func TestSubscribeMilestonesIgnoredAfter(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	// heimdall ignores after and replies with the latest milestone right away
+	mux := http.NewServeMux()
+	mux.HandleFunc(fetchMilestoneSubscribePath, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		writeMilestone(w, 1)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	client := NewHeimdallClient(srv.URL)
+	client.retryInterval = 50 * time.Millisecond
+
+	ch, err := client.SubscribeMilestones(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, big.NewInt(16), receiveMilestone(t, ch).EndBlock)
+
+	// the stale milestone is polled again after the retry interval, not in a loop
+	time.Sleep(250 * time.Millisecond)
+	client.Close()
+
+	require.LessOrEqual(t, calls.Load(), int32(7))
+
+	for range ch {
+	}
+}
+
+func TestSubscribeMilestonesFallback(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	// The long poll endpoint isn't available, the latest milestone advances every
+	// other poll
+	mux := http.NewServeMux()
+	mux.HandleFunc("/milestone/latest", func(w http.ResponseWriter, _ *http.Request) {
+		writeMilestone(w, int64(calls.Add(1)+1)/2)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	client := NewHeimdallClient(srv.URL)
+	client.retryInterval = 10 * time.Millisecond
+
+	ch, err := client.SubscribeMilestones(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, big.NewInt(16), receiveMilestone(t, ch).EndBlock)
+	require.Equal(t, big.NewInt(32), receiveMilestone(t, ch).EndBlock)
+
+	client.Close()
+
+	// Drain the channel until the subscription is closed with the client
+	for range ch {
+	}
+}
@@ -0,0 +1,170 @@
+package heimdall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ErrDNSResolutionFailed is returned if the heimdall host couldn't be resolved and
+// no previously resolved address could be dialed instead
+var ErrDNSResolutionFailed = errors.New("heimdall host resolution failed")
+
+// transportConfig holds the options applied to the http transport of the client
+type transportConfig struct {
+	disableKeepAlives bool
+	proxy             *url.URL
+	staleDNSFallback  bool
+	idleConnTimeout   time.Duration
+	maxConnLifetime   time.Duration
+	maxConnsPerHost   int
+}
+
+// errConnExpired is returned by writes to a connection past its lifetime
+var errConnExpired = errors.New("connection lifetime exceeded")
+
+// newTransport builds the http transport of the client from the default transport,
+// which takes the proxy from the environment unless one is configured
+func newTransport(cfg transportConfig) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableKeepAlives = cfg.disableKeepAlives
+
+	if cfg.proxy != nil {
+		transport.Proxy = http.ProxyURL(cfg.proxy)
+	}
+
+	if cfg.maxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = cfg.maxConnsPerHost
+	}
+
+	if cfg.idleConnTimeout > 0 {
+		transport.IdleConnTimeout = cfg.idleConnTimeout
+	}
+
+	if cfg.staleDNSFallback || cfg.maxConnLifetime > 0 {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		dial := dialer.DialContext
+
+		if cfg.staleDNSFallback {
+			dial = newFallbackDialer(net.DefaultResolver.LookupHost, dial).DialContext
+		}
+
+		if cfg.maxConnLifetime > 0 {
+			dial = withConnLifetime(dial, cfg.maxConnLifetime)
+		}
+
+		transport.DialContext = dial
+	}
+
+	return transport
+}
+
+// withConnLifetime wraps the connections of dial so that they refuse writes once
+// older than lifetime. The transport then drops such an idle connection when it
+// sends the next request over it, and retries the request over a fresh one since
+// nothing was written.
+func withConnLifetime(
+	dial func(ctx context.Context, network, addr string) (net.Conn, error),
+	lifetime time.Duration,
+) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		return &lifetimeConn{Conn: conn, expiresAt: time.Now().Add(lifetime)}, nil
+	}
+}
+
+// lifetimeConn is a connection which can't be written to once expired
+type lifetimeConn struct {
+	net.Conn
+	expiresAt time.Time
+}
+
+func (c *lifetimeConn) Write(b []byte) (int, error) {
+	if time.Now().After(c.expiresAt) {
+		_ = c.Conn.Close()
+		return 0, errConnExpired
+	}
+
+	return c.Conn.Write(b)
+}
+
+// fallbackDialer resolves the hosts itself, remembering the last address of each
+// host it connected to, and dials it when a fresh resolution fails
+type fallbackDialer struct {
+	resolve func(ctx context.Context, host string) ([]string, error)
+	dial    func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	mu       sync.Mutex
+	lastGood map[string]string
+}
+
+func newFallbackDialer(
+	resolve func(ctx context.Context, host string) ([]string, error),
+	dial func(ctx context.Context, network, addr string) (net.Conn, error),
+) *fallbackDialer {
+	return &fallbackDialer{
+		resolve:  resolve,
+		dial:     dial,
+		lastGood: make(map[string]string),
+	}
+}
+
+func (d *fallbackDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return d.dial(ctx, network, addr)
+	}
+
+	ips, resolveErr := d.resolve(ctx, host)
+	if resolveErr == nil {
+		var conn net.Conn
+
+		for _, ip := range ips {
+			if conn, err = d.dial(ctx, network, net.JoinHostPort(ip, port)); err == nil {
+				d.remember(host, ip)
+				return conn, nil
+			}
+		}
+
+		if err == nil {
+			err = fmt.Errorf("no addresses for %s", host)
+		}
+
+		return nil, err
+	}
+
+	d.mu.Lock()
+	ip, ok := d.lastGood[host]
+	d.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s: %v", ErrDNSResolutionFailed, host, resolveErr)
+	}
+
+	log.Warn("Resolving heimdall host failed, dialing its last address", "host", host, "ip", ip, "err", resolveErr)
+
+	conn, err := d.dial(ctx, network, net.JoinHostPort(ip, port))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v, dialing its last address %s: %v", ErrDNSResolutionFailed, host, resolveErr, ip, err)
+	}
+
+	return conn, nil
+}
+
+func (d *fallbackDialer) remember(host, ip string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.lastGood[host] = ip
+}
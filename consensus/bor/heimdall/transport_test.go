@@ -0,0 +1,336 @@
+package heimdall
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/checkpoint"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newCountingServer starts a server serving checkpoint counts which counts the
+// connections dialed to it.
+func newCountingServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var conns atomic.Int32
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(checkpoint.CheckpointCountResponse{
+			Height: "0",
+			Result: checkpoint.CheckpointCount{Result: 1},
+		})
+	}))
+
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	return srv, &conns
+}
+
+func TestKeepAlives(t *testing.T) {
+	t.Parallel()
+
+	const requests = 3
+
+	tests := []struct {
+		name          string
+		opts          []Option
+		expectedConns int32
+	}{
+		{name: "reuse by default", expectedConns: 1},
+		{name: "fresh connection per request", opts: []Option{WithoutKeepAlives()}, expectedConns: requests},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv, conns := newCountingServer(t)
+			client := NewHeimdallClient(srv.URL, test.opts...)
+
+			for i := 0; i < requests; i++ {
+				_, err := client.FetchCheckpointCount(context.Background())
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, test.expectedConns, conns.Load())
+		})
+	}
+}
+
+func TestConnLifetime(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		opts          []Option
+		expectedConns int32
+	}{
+		{name: "reuse by default", expectedConns: 1},
+		{name: "expired connection", opts: []Option{WithMaxConnLifetime(50 * time.Millisecond)}, expectedConns: 2},
+		{name: "idle connection", opts: []Option{WithIdleConnTimeout(50 * time.Millisecond)}, expectedConns: 2},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv, conns := newCountingServer(t)
+
+			// a single attempt, so that failures over an expired connection surface
+			client := NewHeimdallClient(srv.URL, append(test.opts, WithRetryBudget(1))...)
+
+			_, err := client.FetchCheckpointCount(context.Background())
+			require.NoError(t, err)
+
+			time.Sleep(100 * time.Millisecond)
+
+			// the second request dials a new connection, which the third one reuses
+			for i := 0; i < 2; i++ {
+				_, err = client.FetchCheckpointCount(context.Background())
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, test.expectedConns, conns.Load())
+		})
+	}
+}
+
+func TestProxy(t *testing.T) {
+	t.Parallel()
+
+	target, _ := newCountingServer(t)
+
+	// The proxy receives the absolute url of the target and answers on its behalf
+	var proxied atomic.Value
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Store(r.URL.String())
+
+		res, err := http.DefaultTransport.RoundTrip(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer res.Body.Close()
+
+		w.WriteHeader(res.StatusCode)
+		_, _ = io.Copy(w, res.Body)
+	}))
+	t.Cleanup(proxy.Close)
+
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	client := NewHeimdallClient(target.URL, WithProxy(proxyURL))
+
+	count, err := client.FetchCheckpointCount(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+	require.Equal(t, target.URL+fetchCheckpointCount, proxied.Load(), "expect the request to transit the proxy")
+}
+
+func TestSocksProxyTransport(t *testing.T) {
+	t.Parallel()
+
+	proxyURL, err := url.Parse("socks5://localhost:1080")
+	require.NoError(t, err)
+
+	transport := newTransport(transportConfig{proxy: proxyURL})
+
+	req, err := http.NewRequest(http.MethodGet, "http://heimdall:1317/checkpoints/latest", nil)
+	require.NoError(t, err)
+
+	got, err := transport.Proxy(req)
+	require.NoError(t, err)
+	require.Equal(t, proxyURL, got)
+}
+
+func TestSharedTransport(t *testing.T) {
+	t.Parallel()
+
+	srv, conns := newCountingServer(t)
+
+	transport := newTransport(transportConfig{})
+	t.Cleanup(transport.CloseIdleConnections)
+
+	clients := make([]*HeimdallClient, 0, 3)
+	for i := 0; i < 3; i++ {
+		clients = append(clients, NewHeimdallClient(srv.URL, WithTransport(transport)))
+	}
+
+	for _, client := range clients {
+		_, err := client.FetchCheckpointCount(context.Background())
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, int32(1), conns.Load(), "expect the clients to share the connection pool")
+
+	// Closing a client keeps the pooled connection for the others
+	clients[0].Close()
+
+	_, err := clients[1].FetchCheckpointCount(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int32(1), conns.Load())
+}
+
+func TestGzipChunkedResponse(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		// gzip the body regardless of the request, and flush it in pieces, so that it
+		// is sent chunked without a content length
+		w.Header().Set("Content-Encoding", "gzip")
+
+		gz := gzip.NewWriter(w)
+		body := `{"height":"0","result":{"result":42}}`
+
+		for i := 0; i < len(body); i += 8 {
+			end := i + 8
+			if end > len(body) {
+				end = len(body)
+			}
+
+			_, _ = gz.Write([]byte(body[i:end]))
+			_ = gz.Flush()
+			w.(http.Flusher).Flush()
+		}
+
+		_ = gz.Close()
+	}))
+	defer srv.Close()
+
+	// the transport asks for gzip and decompresses itself
+	count, err := NewHeimdallClient(srv.URL).FetchCheckpointCount(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(42), count)
+
+	// a request asking for gzip itself gets the compressed body from the transport
+	build := func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+fetchCheckpointCount, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Accept-Encoding", "gzip")
+
+		return req, nil
+	}
+
+	response, err := FetchWithRequestBuilder[checkpoint.CheckpointCountResponse](context.Background(), http.Client{}, build, make(chan struct{}))
+	require.NoError(t, err)
+	require.Equal(t, int64(42), response.Result.Result)
+}
+
+func TestStaleDNSFallback(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := newCountingServer(t)
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	_, port, err := net.SplitHostPort(u.Host)
+	require.NoError(t, err)
+
+	var resolveErr error
+
+	resolve := func(_ context.Context, host string) ([]string, error) {
+		if resolveErr != nil {
+			return nil, &net.DNSError{Err: resolveErr.Error(), Name: host}
+		}
+
+		return []string{"127.0.0.1"}, nil
+	}
+
+	var dialer net.Dialer
+
+	d := newFallbackDialer(resolve, dialer.DialContext)
+	addr := net.JoinHostPort("heimdall.test", port)
+
+	// a failing resolution without any previous address fails
+	resolveErr = errors.New("no such host")
+
+	_, err = d.DialContext(context.Background(), "tcp", addr)
+	require.ErrorIs(t, err, ErrDNSResolutionFailed)
+
+	// a successful resolution and connection is remembered
+	resolveErr = nil
+
+	conn, err := d.DialContext(context.Background(), "tcp", addr)
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	// and dialed when the resolution fails
+	resolveErr = errors.New("server misbehaving")
+
+	conn, err = d.DialContext(context.Background(), "tcp", addr)
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	// until it can't be dialed either
+	srv.Close()
+
+	_, err = d.DialContext(context.Background(), "tcp", addr)
+	require.ErrorIs(t, err, ErrDNSResolutionFailed)
+}
+
+func TestGzipResponseTooLarge(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+
+		// zeros compress well, so that a small response decompresses past the bound
+		gz := gzip.NewWriter(w)
+		_, _ = gz.Write(make([]byte, maxDecompressedBody+1))
+		_ = gz.Close()
+	}))
+	defer srv.Close()
+
+	for _, encoding := range []string{"", "gzip"} {
+		encoding := encoding
+
+		// the transport decompresses the responses unless the request asks for gzip itself
+		build := func(ctx context.Context) (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+fetchCheckpointCount, nil)
+			if err != nil {
+				return nil, err
+			}
+
+			if encoding != "" {
+				req.Header.Set("Accept-Encoding", encoding)
+			}
+
+			return req, nil
+		}
+
+		// a single attempt, since the error is retried like the other read errors
+		ctx := context.WithValue(context.Background(), maxAttemptsKey{}, 1)
+
+		_, err := FetchWithRequestBuilder[checkpoint.CheckpointCountResponse](ctx, http.Client{}, build, make(chan struct{}))
+		require.ErrorIs(t, err, ErrResponseTooLarge, "Accept-Encoding %q", encoding)
+	}
+}
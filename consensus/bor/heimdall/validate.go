@@ -0,0 +1,134 @@
+package heimdall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/checkpoint"
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/milestone"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	// ErrMissingRequiredField is returned if a response lacks a consensus critical field
+	ErrMissingRequiredField = errors.New("missing required field in Heimdall response")
+
+	// ErrTimestampInFuture is returned if a checkpoint or milestone is timestamped
+	// further ahead of the local clock than the allowed skew
+	ErrTimestampInFuture = errors.New("timestamp is in the future")
+
+	// ErrCheckpointAheadOfTip is returned if a checkpoint ends beyond the latest block
+	// height reported by the status of heimdall
+	ErrCheckpointAheadOfTip = errors.New("checkpoint is ahead of the heimdall tip")
+
+	// ErrCheckpointGap is returned if a checkpoint doesn't start right after the end
+	// of the previous one, leaving a gap or overlapping it
+	ErrCheckpointGap = errors.New("checkpoint doesn't follow the previous one")
+)
+
+// tipCheckAttempts bounds the attempts to fetch the tip for the checkpoint tip check
+const tipCheckAttempts = 3
+
+// validateCheckpoint checks the checkpoint fields according to the client options
+func (h *HeimdallClient) validateCheckpoint(cp *checkpoint.Checkpoint) error {
+	if h.requireFields {
+		switch {
+		case cp.StartBlock == nil:
+			return missingField("start_block")
+		case cp.EndBlock == nil:
+			return missingField("end_block")
+		case cp.RootHash == (common.Hash{}):
+			return missingField("root_hash")
+		}
+	}
+
+	return h.validateTimestamp(cp.Timestamp)
+}
+
+// VerifyCheckpointContinuity checks that the next checkpoint starts at the block
+// following the end of the previous one, failing with ErrCheckpointGap otherwise
+func VerifyCheckpointContinuity(prev, next *checkpoint.Checkpoint) error {
+	switch {
+	case prev == nil || next == nil:
+		return fmt.Errorf("%w: missing checkpoint", ErrCheckpointGap)
+	case prev.EndBlock == nil:
+		return missingField("end_block")
+	case next.StartBlock == nil:
+		return missingField("start_block")
+	}
+
+	expected := new(big.Int).Add(prev.EndBlock, big.NewInt(1))
+
+	if next.StartBlock.Cmp(expected) != 0 {
+		return fmt.Errorf("%w: starts at %v, previous ends at %v", ErrCheckpointGap, next.StartBlock, prev.EndBlock)
+	}
+
+	return nil
+}
+
+// validateMilestone checks the milestone fields according to the client options
+func (h *HeimdallClient) validateMilestone(m *milestone.Milestone) error {
+	if h.requireFields {
+		switch {
+		case m.StartBlock == nil:
+			return missingField("start_block")
+		case m.EndBlock == nil:
+			return missingField("end_block")
+		case m.Hash == (common.Hash{}):
+			return missingField("hash")
+		}
+	}
+
+	return h.validateTimestamp(m.Timestamp)
+}
+
+// validateTimestamp checks that the unix timestamp isn't ahead of the local clock by
+// more than the allowed skew, if any
+func (h *HeimdallClient) validateTimestamp(timestamp uint64) error {
+	if h.maxClockSkew <= 0 {
+		return nil
+	}
+
+	limit := time.Now().Add(h.maxClockSkew)
+
+	if timestamp > uint64(limit.Unix()) {
+		return fmt.Errorf("%w: timestamp %d, max clock skew %v", ErrTimestampInFuture, timestamp, h.maxClockSkew)
+	}
+
+	return nil
+}
+
+// checkCheckpointTip checks that the checkpoint doesn't end beyond the tip of heimdall,
+// the latest block height reported by its status, if enabled with
+// WithCheckpointTipCheck. The check is skipped if the status can't be fetched.
+func (h *HeimdallClient) checkCheckpointTip(ctx context.Context, cp *checkpoint.Checkpoint) error {
+	if !h.checkpointTipCheck || cp.EndBlock == nil {
+		return nil
+	}
+
+	status, err := h.fetchStatus(ctx, tipCheckAttempts)
+	if err != nil {
+		if ctx.Err() != nil || errors.Is(err, ErrShutdownDetected) {
+			return err
+		}
+
+		log.Debug("Skipping the checkpoint tip check without a status", "error", err)
+
+		return nil
+	}
+
+	tip := new(big.Int).SetUint64(status.LatestBlockHeight)
+	if cp.EndBlock.Cmp(tip) > 0 {
+		return fmt.Errorf("%w: end block %v, tip %v", ErrCheckpointAheadOfTip, cp.EndBlock, tip)
+	}
+
+	return nil
+}
+
+func missingField(name string) error {
+	return fmt.Errorf("%w: %s", ErrMissingRequiredField, name)
+}
@@ -0,0 +1,243 @@
+package heimdall
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum/go-ethereum/consensus/bor/heimdall/checkpoint"
+)
+
+// newStaticServer starts a server replying to every request with the given body
+func newStaticServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestRequiredFieldValidation(t *testing.T) {
+	t.Parallel()
+
+	const hash = `"0x0000000000000000000000000000000000000000000000000000000000000001"`
+
+	tests := []struct {
+		name      string
+		milestone bool
+		body      string
+		missing   string
+	}{
+		{
+			name: "complete checkpoint",
+			body: `{"result":{"start_block":1,"end_block":2,"root_hash":` + hash + `}}`,
+		},
+		{
+			name:    "checkpoint without root hash",
+			body:    `{"result":{"start_block":1,"end_block":2}}`,
+			missing: "root_hash",
+		},
+		{
+			name:    "checkpoint without start block",
+			body:    `{"result":{"end_block":2,"root_hash":` + hash + `}}`,
+			missing: "start_block",
+		},
+		{
+			name:    "checkpoint without end block",
+			body:    `{"result":{"start_block":1,"root_hash":` + hash + `}}`,
+			missing: "end_block",
+		},
+		{
+			name:      "complete milestone",
+			milestone: true,
+			body:      `{"result":{"start_block":1,"end_block":2,"hash":` + hash + `}}`,
+		},
+		{
+			name:      "milestone without hash",
+			milestone: true,
+			body:      `{"result":{"start_block":1,"end_block":2}}`,
+			missing:   "hash",
+		},
+		{
+			name:      "milestone without end block",
+			milestone: true,
+			body:      `{"result":{"start_block":1,"hash":` + hash + `}}`,
+			missing:   "end_block",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := newStaticServer(t, test.body)
+
+			fetch := func(client *HeimdallClient) error {
+				if test.milestone {
+					_, err := client.FetchMilestone(context.Background())
+					return err
+				}
+
+				_, err := client.FetchCheckpoint(context.Background(), -1)
+
+				return err
+			}
+
+			// Without the option missing fields are tolerated
+			require.NoError(t, fetch(NewHeimdallClient(srv.URL)))
+
+			err := fetch(NewHeimdallClient(srv.URL, WithRequiredFieldValidation()))
+			if test.missing == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, ErrMissingRequiredField)
+			require.ErrorContains(t, err, test.missing)
+		})
+	}
+}
+
+func TestVerifyCheckpointContinuity(t *testing.T) {
+	t.Parallel()
+
+	newCheckpoint := func(start, end int64) *checkpoint.Checkpoint {
+		return &checkpoint.Checkpoint{StartBlock: big.NewInt(start), EndBlock: big.NewInt(end)}
+	}
+
+	tests := []struct {
+		name       string
+		prev, next *checkpoint.Checkpoint
+		err        error
+	}{
+		{name: "contiguous", prev: newCheckpoint(0, 255), next: newCheckpoint(256, 511)},
+		{name: "gapped", prev: newCheckpoint(0, 255), next: newCheckpoint(257, 511), err: ErrCheckpointGap},
+		{name: "overlapping", prev: newCheckpoint(0, 255), next: newCheckpoint(255, 511), err: ErrCheckpointGap},
+		{name: "same", prev: newCheckpoint(0, 255), next: newCheckpoint(0, 255), err: ErrCheckpointGap},
+		{name: "missing previous", next: newCheckpoint(256, 511), err: ErrCheckpointGap},
+		{name: "missing end block", prev: &checkpoint.Checkpoint{StartBlock: big.NewInt(0)}, next: newCheckpoint(256, 511), err: ErrMissingRequiredField},
+		{name: "missing start block", prev: newCheckpoint(0, 255), next: &checkpoint.Checkpoint{EndBlock: big.NewInt(511)}, err: ErrMissingRequiredField},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := VerifyCheckpointContinuity(test.prev, test.next)
+			if test.err == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, test.err)
+		})
+	}
+}
+
+func TestTimestampInFuture(t *testing.T) {
+	t.Parallel()
+
+	const skew = time.Minute
+
+	tests := []struct {
+		name      string
+		timestamp int64
+		err       error
+	}{
+		{name: "past", timestamp: time.Now().Add(-time.Hour).Unix()},
+		{name: "within skew", timestamp: time.Now().Add(skew / 2).Unix()},
+		{name: "future", timestamp: time.Now().Add(time.Hour).Unix(), err: ErrTimestampInFuture},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			body := fmt.Sprintf(`{"result":{"start_block":1,"end_block":2,"timestamp":%d}}`, test.timestamp)
+			srv := newStaticServer(t, body)
+
+			// Without the option the timestamp isn't checked
+			_, err := NewHeimdallClient(srv.URL).FetchCheckpoint(context.Background(), -1)
+			require.NoError(t, err)
+
+			client := NewHeimdallClient(srv.URL, WithMaxClockSkew(skew))
+
+			_, err = client.FetchCheckpoint(context.Background(), -1)
+			require.ErrorIs(t, err, test.err)
+
+			_, err = client.FetchMilestone(context.Background())
+			require.ErrorIs(t, err, test.err)
+		})
+	}
+}
+
+func TestCheckpointTipCheck(t *testing.T) {
+	t.Parallel()
+
+	newServer := func(checkpointEnd, height int64) string {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/checkpoints/latest", func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = fmt.Fprintf(w, `{"result":{"start_block":0,"end_block":%d}}`, checkpointEnd)
+		})
+		mux.HandleFunc(fetchStatus, func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":-1,"result":{"sync_info":{"latest_block_height":"%d","catching_up":false}}}`, height)
+		})
+
+		srv := httptest.NewServer(mux)
+		t.Cleanup(srv.Close)
+
+		return srv.URL
+	}
+
+	// the checkpoint ends at the tip
+	_, err := NewHeimdallClient(newServer(512, 512), WithCheckpointTipCheck()).FetchCheckpoint(context.Background(), -1)
+	require.NoError(t, err)
+
+	// the checkpoint ends beyond the reported status height
+	ahead := newServer(513, 512)
+
+	_, err = NewHeimdallClient(ahead, WithCheckpointTipCheck()).FetchCheckpoint(context.Background(), -1)
+	require.ErrorIs(t, err, ErrCheckpointAheadOfTip)
+
+	// the check is opt-in
+	_, err = NewHeimdallClient(ahead).FetchCheckpoint(context.Background(), -1)
+	require.NoError(t, err)
+}
+
+func TestCheckpointTipCheckWithoutStatus(t *testing.T) {
+	t.Parallel()
+
+	// heimdall has a checkpoint but doesn't serve its status
+	mux := http.NewServeMux()
+	mux.HandleFunc("/checkpoints/latest", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"result":{"start_block":0,"end_block":512}}`)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := NewHeimdallClient(srv.URL, WithCheckpointTipCheck())
+	defer client.Close()
+
+	client.retryInterval = time.Millisecond
+
+	// the check is skipped without a tip
+	cp, err := client.FetchCheckpoint(context.Background(), -1)
+	require.NoError(t, err)
+	require.Equal(t, int64(512), cp.EndBlock.Int64())
+}